@@ -8,3 +8,11 @@ type Account struct {
 	Address common.Address `json:"address"` // Ethereum account address derived from the key
 	URL     URL            `json:"url"`     // Optional resource locator within a backend
 }
+
+// AccountOutput is an account listed in JSON output mode.
+type AccountOutput struct {
+	Address string `json:"address"`
+	Path    string `json:"path"`
+	Scheme  string `json:"scheme,omitempty"`
+	Used    bool   `json:"used,omitempty"`
+}
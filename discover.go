@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/hwwallet"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/rpc"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+func DiscoverAccounts(term ui.Screen, walletType hwcommon.WalletType, flag *flags.Flags) error {
+	schemes, err := hwwallet.GetPathSchemes(flag.FlagSchemes)
+	if err != nil {
+		return err
+	}
+	var isUsed hwwallet.UsageChecker
+	if flag.FlagRpcUrl != "" {
+		isUsed = accountUsageChecker(rpc.NewClient(flag.FlagRpcUrl))
+	}
+	return hwwallet.DiscoverAccounts(term, walletType, schemes, flag.Max, flag.FlagGapLimit, isUsed, flag.FlagVerbose)
+}
+
+// accountUsageChecker treats an account as used if it has sent a tx or has
+// a balance
+func accountUsageChecker(client *rpc.Client) hwwallet.UsageChecker {
+	return func(addr common.Address) (bool, error) {
+		nonce, err := client.NonceAt(addr)
+		if err != nil {
+			return false, err
+		}
+		if nonce > 0 {
+			return true, nil
+		}
+		balance, err := client.BalanceAt(addr)
+		if err != nil {
+			return false, err
+		}
+		return !balance.IsZero(), nil
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/hwwallet"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/jaanek/jethwallet/wallet"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+const envelopeExt = ".jwenc"
+
+type FileOutput struct {
+	File    string `json:"file"`
+	Address string `json:"address,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+// EncryptFile encrypts a file locally with a random data key wrapped by the hw
+// wallet. The output envelope records how the key was wrapped.
+func EncryptFile(term ui.Screen, flag *flags.Flags) error {
+	if flag.FlagFrom == "" && flag.Hdpath == "" {
+		return errors.New("Missing --from or --hd")
+	}
+	if flag.FlagFile == "" {
+		return errors.New("Missing --file")
+	}
+	if flag.FlagKeyName == "" {
+		return errors.New("Missing --key")
+	}
+	out := flag.FlagOut
+	if out == "" {
+		out = flag.FlagFile + envelopeExt
+	}
+	in, err := os.Open(flag.FlagFile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// wrap a new data key on device
+	dataKey, err := wallet.NewDataKey()
+	if err != nil {
+		return err
+	}
+	var fromAddr common.Address
+	if flag.FlagFrom != "" {
+		fromAddr = common.HexToAddress(flag.FlagFrom)
+	}
+	walletType := hwcommon.GetWalletTypeFromFlags(flag)
+	opts := hwcommon.CipherOptions{AskOnEncrypt: flag.FlagAskOnEncrypt, AskOnDecrypt: flag.FlagAskOnDecrypt}
+	acc, wrappedKey, err := hwwallet.Encrypt(term, walletType, fromAddr, flag.Hdpath, flag.FlagKeyName, dataKey, opts, flag.Max)
+	if err != nil {
+		return fmt.Errorf("error while encrypting data key: %w", err)
+	}
+	header := &wallet.EnvelopeHeader{
+		Address:      acc.Address.Hex(),
+		Path:         acc.URL.Path,
+		KeyName:      flag.FlagKeyName,
+		AskOnEncrypt: flag.FlagAskOnEncrypt,
+		AskOnDecrypt: flag.FlagAskOnDecrypt,
+		WrappedKey:   wrappedKey,
+	}
+	err = writeFileAtomic(out, func(w io.Writer) error {
+		return wallet.WriteEnvelope(w, header, dataKey, in)
+	})
+	if err != nil {
+		return err
+	}
+	term.Result(fmt.Sprintf("%s\n", out), &FileOutput{File: out, Address: header.Address, Path: header.Path})
+	return nil
+}
+
+// DecryptFile decrypts an envelope written by EncryptFile. The account, key
+// name and ask flags are read from the envelope header.
+func DecryptFile(term ui.Screen, flag *flags.Flags) error {
+	if flag.FlagFile == "" {
+		return errors.New("Missing --file")
+	}
+	out := flag.FlagOut
+	if out == "" {
+		if !strings.HasSuffix(flag.FlagFile, envelopeExt) {
+			return errors.New("Missing --out")
+		}
+		out = strings.TrimSuffix(flag.FlagFile, envelopeExt)
+	}
+	in, err := os.Open(flag.FlagFile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	reader := bufio.NewReader(in)
+	header, err := wallet.ReadEnvelopeHeader(reader)
+	if err != nil {
+		return err
+	}
+	term.Logf("Envelope of %s, path: %s, key: %s\n", header.Address, header.Path, header.KeyName)
+
+	// unwrap the data key on device
+	var fromAddr common.Address
+	if header.Address != "" {
+		fromAddr = common.HexToAddress(header.Address)
+	}
+	walletType := hwcommon.GetWalletTypeFromFlags(flag)
+	opts := hwcommon.CipherOptions{AskOnEncrypt: header.AskOnEncrypt, AskOnDecrypt: header.AskOnDecrypt}
+	dataKey, err := hwwallet.Decrypt(term, walletType, fromAddr, header.Path, header.KeyName, header.WrappedKey, opts, flag.Max)
+	if err != nil {
+		return fmt.Errorf("error while decrypting data key: %w", err)
+	}
+	err = writeFileAtomic(out, func(w io.Writer) error {
+		return wallet.ReadEnvelopeBody(w, reader, header, dataKey)
+	})
+	if err != nil {
+		return err
+	}
+	term.Result(fmt.Sprintf("%s\n", out), &FileOutput{File: out, Address: header.Address, Path: header.Path})
+	return nil
+}
+
+// writeFileAtomic writes a new file through a temp file, so a failed write
+// leaves no partial output. Existing files are not overwritten.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	if _, err := os.Stat(path); err == nil {
+		return errors.New(fmt.Sprintf("Output file already exists: %s", path))
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
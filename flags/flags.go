@@ -8,32 +8,90 @@ type Flags struct {
 	Hdpath       string
 	Max          int
 	FlagVerbose  bool
+	FlagJSON     bool
+
+	// hw wallet account cache
+	AccountCachePath string
+	NoAccountCache   bool
+
+	// list accounts params
+	FlagDiscover bool
+	FlagGapLimit int
+	FlagSchemes  string
+	FlagXPub     string
+	FlagXPubPath string
+	FlagWatch    bool
 
 	// sign tx params
-	FlagNonce         string
-	FlagFrom          string
-	FlagTo            string
-	FlagGasLimit      string
-	FlagGasPrice      string
-	FlagGasTip        string
-	FlagGasFeeCap     string
-	FlagValue         string
-	FlagValueGwei     bool
-	FlagGasPriceGwei  bool
-	FlagGasTipGwei    bool
-	FlagGasFeeCapGwei bool
-	FlagValueEth      bool
-	FlagRpcUrl        string
-	FlagChainID       string
-	FlagInput         string
-	FlagInputMethod   string
-	FlagSig           bool
-	Plain             bool
+	FlagNonce            string
+	FlagFrom             string
+	FlagTo               string
+	FlagGasLimit         string
+	FlagGasPrice         string
+	FlagGasTip           string
+	FlagGasFeeCap        string
+	FlagValue            string
+	FlagValueGwei        bool
+	FlagGasPriceGwei     bool
+	FlagGasTipGwei       bool
+	FlagGasFeeCapGwei    bool
+	FlagValueEth         bool
+	FlagRpcUrl           string
+	FlagChainID          string
+	FlagInput            string
+	FlagInputMethod      string
+	FlagMethod           string
+	FlagArgs             string
+	FlagAbi              string
+	FlagAbiDir           string
+	FlagSigFile          string
+	FlagTxType           string
+	FlagAccessList       string
+	FlagAccessListFile   string
+	FlagCreateAccessList bool
+	FlagDescriptors      string
+	FlagSig              bool
+	Plain                bool
+
+	// send tx params
+	FlagWait        bool
+	FlagWaitTimeout int
 
 	// sign msg, recover params
 	FlagAddEthPrefix bool
 	FlagSignature    string
 
+	// sign typed data params
+	FlagFile string
+
 	// encrypt, decrypt params
-	FlagKey string
+	FlagKey          string
+	FlagKeyName      string
+	FlagOut          string
+	FlagAskOnEncrypt bool
+	FlagAskOnDecrypt bool
+	FlagIv           string
+	FlagNoPadding    bool
+
+	// trezor management params
+	FlagLabel      string
+	FlagPassphrase string
+	FlagRemove     bool
+	FlagYes        bool
+	FlagMessage    string
+	FlagButton     bool
+	FlagPin        bool
+	FlagStrength   int
+	FlagSkipBackup bool
+	FlagWordCount  int
+	FlagMatrix     bool
+	FlagDryRun     bool
+
+	// trezor identity params
+	FlagIdentity      string
+	FlagIdentityIndex uint
+	FlagChallenge     string
+	FlagVisual        string
+	FlagCurve         string
+	FlagPeerKey       string
 }
@@ -0,0 +1,215 @@
+// Package hdkey implements BIP32 extended public keys, used to derive
+// watch-only addresses without a device.
+package hdkey
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/jaanek/jethwallet/accounts"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/crypto"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const (
+	// HardenedKeyStart is the index of the first hardened child key
+	HardenedKeyStart = 0x80000000
+	// serialized length of an extended key without checksum
+	serializedKeyLen = 78
+)
+
+// XPubVersion is the mainnet version prefix of extended public keys
+var XPubVersion = []byte{0x04, 0x88, 0xb2, 0x1e}
+
+var (
+	ErrHardenedChild  = errors.New("hdkey: can not derive a hardened child from a public key")
+	ErrInvalidChild   = errors.New("hdkey: invalid child, use the next index")
+	ErrInvalidKeyLen  = errors.New("hdkey: invalid extended key length")
+	ErrInvalidVersion = errors.New("hdkey: not an extended public key")
+	ErrBadChecksum    = errors.New("hdkey: bad extended key checksum")
+)
+
+// ExtendedKey is a BIP32 extended public key
+type ExtendedKey struct {
+	Depth     uint8
+	ParentFP  uint32 // fingerprint of the parent key
+	ChildNum  uint32
+	ChainCode []byte // 32 bytes
+	PubKey    []byte // 33 bytes compressed
+}
+
+// NewExtendedKey creates an extended key from a compressed (33 bytes) or
+// uncompressed (65 bytes) public key and chain code
+func NewExtendedKey(pubKey []byte, chainCode []byte, depth uint8, parentFP uint32, childNum uint32) (*ExtendedKey, error) {
+	if len(chainCode) != 32 {
+		return nil, fmt.Errorf("hdkey: invalid chain code length: %d", len(chainCode))
+	}
+	var compressed []byte
+	switch len(pubKey) {
+	case 33:
+		if _, err := crypto.DecompressPubkey(pubKey); err != nil {
+			return nil, fmt.Errorf("hdkey: invalid public key: %w", err)
+		}
+		compressed = common.CopyBytes(pubKey)
+	case 65:
+		key, err := crypto.UnmarshalPubkey(pubKey)
+		if err != nil {
+			return nil, fmt.Errorf("hdkey: invalid public key: %w", err)
+		}
+		compressed = crypto.CompressPubkey(key)
+	default:
+		return nil, fmt.Errorf("hdkey: invalid public key length: %d", len(pubKey))
+	}
+	return &ExtendedKey{
+		Depth:     depth,
+		ParentFP:  parentFP,
+		ChildNum:  childNum,
+		ChainCode: common.CopyBytes(chainCode),
+		PubKey:    compressed,
+	}, nil
+}
+
+// ParseXPub parses a base58 serialized extended public key
+func ParseXPub(xpub string) (*ExtendedKey, error) {
+	decoded, err := base58Decode(xpub)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) != serializedKeyLen+4 {
+		return nil, ErrInvalidKeyLen
+	}
+	payload, checksum := decoded[:serializedKeyLen], decoded[serializedKeyLen:]
+	if !bytes.Equal(doubleSha256(payload)[:4], checksum) {
+		return nil, ErrBadChecksum
+	}
+	if !bytes.Equal(payload[:4], XPubVersion) {
+		return nil, ErrInvalidVersion
+	}
+	return NewExtendedKey(
+		payload[45:78],
+		payload[13:45],
+		payload[4],
+		binary.BigEndian.Uint32(payload[5:9]),
+		binary.BigEndian.Uint32(payload[9:13]),
+	)
+}
+
+// String returns the base58 serialized extended public key
+func (k *ExtendedKey) String() string {
+	payload := make([]byte, 0, serializedKeyLen+4)
+	payload = append(payload, XPubVersion...)
+	payload = append(payload, k.Depth)
+	payload = append(payload, uint32Bytes(k.ParentFP)...)
+	payload = append(payload, uint32Bytes(k.ChildNum)...)
+	payload = append(payload, k.ChainCode...)
+	payload = append(payload, k.PubKey...)
+	payload = append(payload, doubleSha256(payload)[:4]...)
+	return base58Encode(payload)
+}
+
+// Fingerprint returns the key fingerprint used as parent fingerprint of its
+// children
+func (k *ExtendedKey) Fingerprint() uint32 {
+	return binary.BigEndian.Uint32(hash160(k.PubKey)[:4])
+}
+
+// PubKeyFingerprint returns the fingerprint of a compressed (33 bytes) or
+// uncompressed (65 bytes) public key
+func PubKeyFingerprint(pubKey []byte) (uint32, error) {
+	switch len(pubKey) {
+	case 33:
+	case 65:
+		key, err := crypto.UnmarshalPubkey(pubKey)
+		if err != nil {
+			return 0, fmt.Errorf("hdkey: invalid public key: %w", err)
+		}
+		pubKey = crypto.CompressPubkey(key)
+	default:
+		return 0, fmt.Errorf("hdkey: invalid public key length: %d", len(pubKey))
+	}
+	return binary.BigEndian.Uint32(hash160(pubKey)[:4]), nil
+}
+
+// Child derives a non-hardened child public key
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	if index >= HardenedKeyStart {
+		return nil, ErrHardenedChild
+	}
+	mac := hmac.New(sha512.New, k.ChainCode)
+	mac.Write(k.PubKey)
+	mac.Write(uint32Bytes(index))
+	sum := mac.Sum(nil)
+	il, ir := sum[:32], sum[32:]
+
+	curve := crypto.S256()
+	ilNum := new(big.Int).SetBytes(il)
+	if ilNum.Cmp(curve.Params().N) >= 0 || ilNum.Sign() == 0 {
+		return nil, ErrInvalidChild
+	}
+	parent, err := crypto.DecompressPubkey(k.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	ilx, ily := curve.ScalarBaseMult(il)
+	x, y := curve.Add(ilx, ily, parent.X, parent.Y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, ErrInvalidChild
+	}
+	child := crypto.CompressPubkey(&ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+	return &ExtendedKey{
+		Depth:     k.Depth + 1,
+		ParentFP:  k.Fingerprint(),
+		ChildNum:  index,
+		ChainCode: common.CopyBytes(ir),
+		PubKey:    child,
+	}, nil
+}
+
+// Derive derives a descendant key by a relative path of non-hardened indexes
+func (k *ExtendedKey) Derive(path accounts.DerivationPath) (*ExtendedKey, error) {
+	key := k
+	for _, index := range path {
+		var err error
+		key, err = key.Child(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Address returns the ethereum address of the public key
+func (k *ExtendedKey) Address() (common.Address, error) {
+	pubKey, err := crypto.DecompressPubkey(k.PubKey)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+func uint32Bytes(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func doubleSha256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+func hash160(data []byte) []byte {
+	sha := sha256.Sum256(data)
+	hasher := ripemd160.New()
+	hasher.Write(sha[:])
+	return hasher.Sum(nil)
+}
@@ -0,0 +1,76 @@
+package hdkey
+
+import (
+	"errors"
+	"testing"
+)
+
+// BIP32 test vector 1 keys at m/0H/1/2H/2 and m/0H/1/2H/2/1000000000
+const (
+	vector1XPub      = "xpub6FHa3pjLCk84BayeJxFW2SP4XRrFd1JYnxeLeU8EqN3vDfZmbqBqaGJAyiLjTAwm6ZLRQUMv1ZACTj37sR62cfN7fe5JnJ7dh8zL4fiyLHV"
+	vector1ChildXPub = "xpub6H1LXWLaKsWFhvm6RVpEL9P4KfRZSW7abD2ttkWP3SSQvnyA8FSVqNTEcYFgJS2UaFcxupHiYkro49S8yGasTvXEYBVPamhGW6cFJodrTHy"
+)
+
+func TestParseXPubRoundTrip(t *testing.T) {
+	key, err := ParseXPub(vector1XPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Depth != 4 || key.ChildNum != 2 {
+		t.Fatalf("unexpected depth %d and child %d", key.Depth, key.ChildNum)
+	}
+	if got := key.String(); got != vector1XPub {
+		t.Fatalf("round trip: got %s, want %s", got, vector1XPub)
+	}
+}
+
+func TestChild(t *testing.T) {
+	key, err := ParseXPub(vector1XPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := key.Child(1000000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := child.String(); got != vector1ChildXPub {
+		t.Fatalf("child: got %s, want %s", got, vector1ChildXPub)
+	}
+	if child.ParentFP != key.Fingerprint() {
+		t.Fatalf("child parent fingerprint %x, want %x", child.ParentFP, key.Fingerprint())
+	}
+}
+
+func TestChildHardened(t *testing.T) {
+	key, err := ParseXPub(vector1XPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := key.Child(HardenedKeyStart); !errors.Is(err, ErrHardenedChild) {
+		t.Fatalf("expected ErrHardenedChild, got %v", err)
+	}
+}
+
+func TestParseXPubBadChecksum(t *testing.T) {
+	decoded, err := base58Decode(vector1XPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded[len(decoded)-1] ^= 0x01
+	if _, err := ParseXPub(base58Encode(decoded)); !errors.Is(err, ErrBadChecksum) {
+		t.Fatalf("expected ErrBadChecksum, got %v", err)
+	}
+}
+
+func TestParseXPubWrongVersion(t *testing.T) {
+	decoded, err := base58Decode(vector1XPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an xprv version with a valid checksum
+	payload := append([]byte{0x04, 0x88, 0xad, 0xe4}, decoded[4:serializedKeyLen]...)
+	payload = append(payload, doubleSha256(payload)[:4]...)
+	if _, err := ParseXPub(base58Encode(payload)); !errors.Is(err, ErrInvalidVersion) {
+		t.Fatalf("expected ErrInvalidVersion, got %v", err)
+	}
+}
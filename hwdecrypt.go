@@ -14,8 +14,8 @@ import (
 )
 
 func HwDecrypt(term ui.Screen, flag *flags.Flags) error {
-	if flag.FlagFrom == "" {
-		return errors.New("Missing --from address")
+	if flag.FlagFrom == "" && flag.Hdpath == "" {
+		return errors.New("Missing --from address or --hd")
 	}
 	if flag.FlagKey == "" {
 		return errors.New("Missing --key")
@@ -23,22 +23,33 @@ func HwDecrypt(term ui.Screen, flag *flags.Flags) error {
 	if flag.FlagInput == "" {
 		return errors.New("Missing --data")
 	}
-	fromAddr := common.HexToAddress(flag.FlagFrom)
+	var fromAddr common.Address
+	if flag.FlagFrom != "" {
+		fromAddr = common.HexToAddress(flag.FlagFrom)
+	}
+	opts, err := cipherOptionsFromFlags(flag)
+	if err != nil {
+		return err
+	}
 	key := []byte(flag.FlagKey)
 	if strings.HasPrefix(flag.FlagKey, "0x") {
-		key = hexutil.MustDecode(flag.FlagKey)
+		if key, err = hexutil.Decode(flag.FlagKey); err != nil {
+			return fmt.Errorf("Invalid --key: %w", err)
+		}
 	}
 	data := []byte(flag.FlagInput)
 	if strings.HasPrefix(flag.FlagInput, "0x") {
-		data = hexutil.MustDecode(flag.FlagInput)
+		if data, err = hexutil.Decode(flag.FlagInput); err != nil {
+			return fmt.Errorf("Invalid --data: %w", err)
+		}
 	}
 
 	// encrypt data
 	walletType := hwcommon.GetWalletTypeFromFlags(flag)
-	decrypted, err := hwwallet.Decrypt(term, walletType, fromAddr, key, data, flag.Max)
+	decrypted, err := hwwallet.Decrypt(term, walletType, fromAddr, flag.Hdpath, string(key), data, opts, flag.Max)
 	if err != nil {
 		return fmt.Errorf("error while decrypting: %w", err)
 	}
-	term.Output(string(decrypted))
+	term.Result(string(decrypted), &DataOutput{Data: string(decrypted)})
 	return nil
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strings"
@@ -13,9 +14,13 @@ import (
 	"github.com/ledgerwatch/erigon/common/hexutil"
 )
 
+type DataOutput struct {
+	Data string `json:"data"`
+}
+
 func HwEncrypt(term ui.Screen, flag *flags.Flags) error {
-	if flag.FlagFrom == "" {
-		return errors.New("Missing --from address")
+	if flag.FlagFrom == "" && flag.Hdpath == "" {
+		return errors.New("Missing --from address or --hd")
 	}
 	if flag.FlagKey == "" {
 		return errors.New("Missing --key")
@@ -23,22 +28,54 @@ func HwEncrypt(term ui.Screen, flag *flags.Flags) error {
 	if flag.FlagInput == "" {
 		return errors.New("Missing --data")
 	}
-	fromAddr := common.HexToAddress(flag.FlagFrom)
+	var fromAddr common.Address
+	if flag.FlagFrom != "" {
+		fromAddr = common.HexToAddress(flag.FlagFrom)
+	}
+	opts, err := cipherOptionsFromFlags(flag)
+	if err != nil {
+		return err
+	}
 	key := []byte(flag.FlagKey)
 	if strings.HasPrefix(flag.FlagKey, "0x") {
-		key = hexutil.MustDecode(flag.FlagKey)
+		if key, err = hexutil.Decode(flag.FlagKey); err != nil {
+			return fmt.Errorf("Invalid --key: %w", err)
+		}
 	}
 	data := []byte(flag.FlagInput)
 	if strings.HasPrefix(flag.FlagInput, "0x") {
-		data = hexutil.MustDecode(flag.FlagInput)
+		if data, err = hexutil.Decode(flag.FlagInput); err != nil {
+			return fmt.Errorf("Invalid --data: %w", err)
+		}
 	}
 
 	// encrypt data
 	walletType := hwcommon.GetWalletTypeFromFlags(flag)
-	encrypted, err := hwwallet.Encrypt(term, walletType, fromAddr, key, data, flag.Max)
+	_, encrypted, err := hwwallet.Encrypt(term, walletType, fromAddr, flag.Hdpath, string(key), data, opts, flag.Max)
 	if err != nil {
 		return fmt.Errorf("error while encrypting: %w", err)
 	}
-	term.Output(hexutil.Encode(encrypted[:]))
+	term.Result(hexutil.Encode(encrypted[:]), &DataOutput{Data: hexutil.Encode(encrypted[:])})
 	return nil
 }
+
+// cipherOptionsFromFlags returns the encryption options of --iv,
+// --ask-on-encrypt, --ask-on-decrypt and --no-padding
+func cipherOptionsFromFlags(flag *flags.Flags) (hwcommon.CipherOptions, error) {
+	opts := hwcommon.CipherOptions{
+		AskOnEncrypt: flag.FlagAskOnEncrypt,
+		AskOnDecrypt: flag.FlagAskOnDecrypt,
+		NoPadding:    flag.FlagNoPadding,
+	}
+	if flag.FlagIv != "" {
+		iv, err := hex.DecodeString(strings.TrimPrefix(flag.FlagIv, "0x"))
+		if err != nil {
+			return hwcommon.CipherOptions{}, fmt.Errorf("Invalid --iv: %w", err)
+		}
+		if len(iv) != 16 {
+			return hwcommon.CipherOptions{}, errors.New(fmt.Sprintf("Invalid --iv length: %d, must be 16 bytes", len(iv)))
+		}
+		opts.Iv = iv
+	}
+	return opts, nil
+}
@@ -0,0 +1,158 @@
+package hwwallet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jaanek/jethwallet/accounts"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+// AccountCachePath is the file of account cache. Empty disables the cache.
+var AccountCachePath = DefaultAccountCachePath()
+
+// AccountCache records derivation paths of already found accounts by device
+// id, so an account can be used without deriving all paths again. Cached
+// paths are always verified with the device before use.
+type AccountCache struct {
+	path    string
+	Devices map[string]map[common.Address]string `json:"devices"`
+}
+
+// DefaultAccountCachePath returns ~/.jethwallet/accounts.json or empty if
+// there is no home directory
+func DefaultAccountCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".jethwallet", "accounts.json")
+}
+
+// LoadAccountCache reads the cache file. A missing file gives an empty cache.
+func LoadAccountCache(path string) (*AccountCache, error) {
+	cache := &AccountCache{
+		path:    path,
+		Devices: make(map[string]map[common.Address]string),
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, err
+	}
+	if cache.Devices == nil {
+		cache.Devices = make(map[string]map[common.Address]string)
+	}
+	return cache, nil
+}
+
+func (c *AccountCache) Lookup(deviceID string, addr common.Address) (string, bool) {
+	path, ok := c.Devices[deviceID][addr]
+	return path, ok
+}
+
+// Add records an account path and reports whether the cache changed
+func (c *AccountCache) Add(deviceID string, addr common.Address, path string) bool {
+	if deviceID == "" {
+		return false
+	}
+	paths, ok := c.Devices[deviceID]
+	if !ok {
+		paths = make(map[common.Address]string)
+		c.Devices[deviceID] = paths
+	}
+	if paths[addr] == path {
+		return false
+	}
+	paths[addr] = path
+	return true
+}
+
+// Save writes the cache file atomically
+func (c *AccountCache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(c.path), "."+filepath.Base(c.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	return os.Rename(f.Name(), c.path)
+}
+
+// loadAccountCache returns the cache or nil if it is disabled or can not be read
+func loadAccountCache(term ui.Screen) *AccountCache {
+	if AccountCachePath == "" {
+		return nil
+	}
+	cache, err := LoadAccountCache(AccountCachePath)
+	if err != nil {
+		term.Errorf("Ignoring account cache %s: %v\n", AccountCachePath, err)
+		return nil
+	}
+	return cache
+}
+
+// cacheAccounts records the found accounts of a wallet
+func cacheAccounts(term ui.Screen, w hwcommon.HWWallet, accs []accounts.Account) {
+	cache := loadAccountCache(term)
+	if cache == nil {
+		return
+	}
+	changed := false
+	for _, acc := range accs {
+		if cache.Add(w.DeviceID(), acc.Address, acc.URL.Path) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := cache.Save(); err != nil {
+		term.Errorf("Error while saving account cache: %v\n", err)
+	}
+}
+
+// findAccount finds the wallet and path of an address, first from the account
+// cache verified with a single derive and then by deriving max paths
+func findAccount(term ui.Screen, wallets []hwcommon.HWWallet, fromAddr common.Address, max int) (hwcommon.HWWallet, accounts.Account) {
+	cache := loadAccountCache(term)
+	if cache != nil {
+		for _, w := range wallets {
+			pathstr, ok := cache.Lookup(w.DeviceID(), fromAddr)
+			if !ok {
+				continue
+			}
+			acc, err := Account(w, pathstr)
+			if err == nil && acc.Address == fromAddr {
+				term.Logf("Found account from cache: %v, path: %s\n", acc.Address, pathstr)
+				return w, acc
+			}
+			term.Logf("Cached path %s of %v does not match: %v\n", pathstr, fromAddr, err)
+		}
+	}
+	hww, acc, _ := FindOneFromWallets(term, wallets, fromAddr, DefaultHDPaths, max)
+	if hww != nil && acc != (accounts.Account{}) {
+		cacheAccounts(term, hww, []accounts.Account{acc})
+	}
+	return hww, acc
+}
@@ -0,0 +1,159 @@
+package hwwallet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jaanek/jethwallet/accounts"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+// PathScheme is a named derivation path layout walked by an iterator
+type PathScheme struct {
+	Name     string
+	Base     accounts.DerivationPath
+	Iterator func(base accounts.DerivationPath) func() accounts.DerivationPath
+}
+
+var (
+	// DiscoverySchemes are the path schemes walked by account discovery
+	DiscoverySchemes = []PathScheme{
+		{Name: "ledger-live", Base: accounts.DefaultBaseDerivationPath, Iterator: accounts.LedgerLiveIterator},     // m/44'/60'/N'/0/0
+		{Name: "ledger-legacy", Base: accounts.LegacyLedgerBaseDerivationPath, Iterator: accounts.DefaultIterator}, // m/44'/60'/0'/N
+		{Name: "bip44", Base: accounts.DefaultBaseDerivationPath, Iterator: accounts.DefaultIterator},              // m/44'/60'/0'/0/N
+	}
+)
+
+// UsageChecker reports whether an account has been used on chain
+type UsageChecker func(addr common.Address) (bool, error)
+
+// DiscoveredAccount is an account found by discovery with the scheme of its path
+type DiscoveredAccount struct {
+	accounts.Account
+	Scheme string
+	Used   bool
+}
+
+// GetPathSchemes returns discovery schemes by comma separated names or all
+// schemes if names is empty
+func GetPathSchemes(names string) ([]PathScheme, error) {
+	if names == "" {
+		return DiscoverySchemes, nil
+	}
+	schemes := []PathScheme{}
+	for _, name := range strings.Split(names, ",") {
+		found := false
+		for _, scheme := range DiscoverySchemes {
+			if scheme.Name == strings.TrimSpace(name) {
+				schemes = append(schemes, scheme)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New(fmt.Sprintf("Unknown path scheme: %s", name))
+		}
+	}
+	return schemes, nil
+}
+
+func DiscoverAccounts(term ui.Screen, walletType hwcommon.WalletType, schemes []PathScheme, max int, gapLimit int, isUsed UsageChecker, verbose bool) error {
+	wallets, err := GetWallets(term, walletType)
+	if err != nil {
+		return err
+	}
+	term.Logf("Found %d wallet(s)\n", len(wallets))
+	for _, w := range wallets {
+		term.Logf("Wallet status: %s\n", w.Status())
+		accs, err := Discover(term, w, schemes, max, gapLimit, isUsed)
+		if err != nil {
+			return err
+		}
+		found := make([]accounts.Account, 0, len(accs))
+		for _, acc := range accs {
+			found = append(found, acc.Account)
+		}
+		cacheAccounts(term, w, found)
+		for _, acc := range accs {
+			result := &accounts.AccountOutput{Address: acc.Address.Hex(), Path: acc.URL.Path, Scheme: acc.Scheme, Used: acc.Used}
+			if verbose {
+				term.Result(fmt.Sprintf("%s hd-path-%s %s\n", acc.Address.Hex(), acc.URL.Path, acc.Scheme), result)
+			} else {
+				term.Result(fmt.Sprintf("%s\n", acc.Address.Hex()), result)
+			}
+		}
+	}
+	return nil
+}
+
+// Discover walks the given path schemes. Without usage checker the first
+// max+1 accounts of each scheme are returned. With usage checker a scheme is
+// walked until gapLimit unused accounts in a row and the used accounts plus
+// the next fresh one are returned. Paths shared by schemes are returned once.
+func Discover(term ui.Screen, wallet hwcommon.HWWallet, schemes []PathScheme, max int, gapLimit int, isUsed UsageChecker) ([]DiscoveredAccount, error) {
+	if isUsed != nil && gapLimit <= 0 {
+		return nil, errors.New("gap limit must be positive")
+	}
+	found := []DiscoveredAccount{}
+	seen := make(map[string]bool)
+	add := func(acc DiscoveredAccount) {
+		if seen[acc.URL.Path] {
+			return
+		}
+		seen[acc.URL.Path] = true
+		found = append(found, acc)
+	}
+	for _, scheme := range schemes {
+		next := scheme.Iterator(scheme.Base)
+		var fresh *DiscoveredAccount
+		for i, gap := 0, 0; ; i++ {
+			if isUsed == nil && i > max {
+				break
+			}
+			if isUsed != nil && gap >= gapLimit {
+				break
+			}
+			path := next()
+			addr, err := wallet.Derive(path)
+			if err != nil {
+				return nil, err
+			}
+			acc := DiscoveredAccount{
+				Account: accounts.Account{
+					Address: addr,
+					URL: accounts.URL{
+						Scheme: wallet.Scheme(),
+						Path:   path.String(),
+					},
+				},
+				Scheme: scheme.Name,
+			}
+			if isUsed == nil {
+				add(acc)
+				continue
+			}
+			acc.Used, err = isUsed(addr)
+			if err != nil {
+				return nil, err
+			}
+			term.Logf("%s: %s %s used: %v\n", scheme.Name, path, addr.Hex(), acc.Used)
+			if acc.Used {
+				add(acc)
+				gap = 0
+				fresh = nil
+			} else {
+				if fresh == nil {
+					fresh = &acc
+				}
+				gap++
+			}
+		}
+		if fresh != nil {
+			add(*fresh)
+		}
+	}
+	return found, nil
+}
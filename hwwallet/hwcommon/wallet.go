@@ -4,6 +4,7 @@ import (
 	"github.com/holiman/uint256"
 	"github.com/jaanek/jethwallet/accounts"
 	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/hdkey"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core/types"
 )
@@ -19,11 +20,27 @@ type HWWallet interface {
 	Scheme() string
 	Status() string
 	Label() string
+	DeviceID() string
 	Derive(path accounts.DerivationPath) (common.Address, error)
+	ShowAddress(path accounts.DerivationPath) (common.Address, error)
+	ExtendedPublicKey(path accounts.DerivationPath) (*hdkey.ExtendedKey, error)
 	SignTx(path accounts.DerivationPath, tx types.Transaction, chainID *uint256.Int) (common.Address, types.Transaction, error)
 	SignMessage(path accounts.DerivationPath, msg []byte) (common.Address, []byte, error)
-	Encrypt(path accounts.DerivationPath, key string, data []byte, askOnEncrypt, askOnDecrypt bool) ([]byte, error)
-	Decrypt(path accounts.DerivationPath, key string, data []byte, askOnEncrypt, askOnDecrypt bool) ([]byte, error)
+	SignTypedMessage(path accounts.DerivationPath, domainHash []byte, messageHash []byte) (common.Address, []byte, error)
+	VerifyMessage(address common.Address, signature []byte, msg []byte) error
+	Encrypt(path accounts.DerivationPath, key string, data []byte, opts CipherOptions) ([]byte, error)
+	Decrypt(path accounts.DerivationPath, key string, data []byte, opts CipherOptions) ([]byte, error)
+}
+
+// CipherOptions are the SLIP-11 encryption options. Only the key name and the
+// ask flags feed the SLIP-11 key derivation, the IV is the AES-CBC IV of the
+// data. Data must be decrypted with the same key name and options it was
+// encrypted with.
+type CipherOptions struct {
+	Iv           []byte // AES-CBC IV, empty lets the device derive it from the key
+	AskOnEncrypt bool
+	AskOnDecrypt bool
+	NoPadding    bool // data is a multiple of 16 bytes and not pkcs7 padded
 }
 
 func GetWalletTypeFromFlags(flag *flags.Flags) WalletType {
@@ -50,17 +50,21 @@ func ListAccounts(term ui.Screen, walletType hwcommon.WalletType, hdpath string,
 					return err
 				}
 				term.Logf("%s %s", acc.Address.Hex(), acc.URL.Path)
+				term.Result("", &accounts.AccountOutput{Address: acc.Address.Hex(), Path: acc.URL.Path})
+				cacheAccounts(term, w, []accounts.Account{acc})
 				break
 			}
 			accs, err := Accounts(w, DefaultHDPaths, max)
 			if err != nil {
 				return err
 			}
+			cacheAccounts(term, w, accs)
 			for _, acc := range accs {
+				result := &accounts.AccountOutput{Address: acc.Address.Hex(), Path: acc.URL.Path}
 				if verbose {
-					term.Output(fmt.Sprintf("%s hd-path-%s\n", acc.Address.Hex(), acc.URL.Path))
+					term.Result(fmt.Sprintf("%s hd-path-%s\n", acc.Address.Hex(), acc.URL.Path), result)
 				} else {
-					term.Output(fmt.Sprintf("%s\n", acc.Address.Hex()))
+					term.Result(fmt.Sprintf("%s\n", acc.Address.Hex()), result)
 				}
 			}
 		}
@@ -70,13 +74,15 @@ func ListAccounts(term ui.Screen, walletType hwcommon.WalletType, hdpath string,
 	return nil
 }
 
-func SignTx(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Address, tx types.Transaction, max int) (types.Transaction, error) {
+// SignTx signs the tx with the account of fromAddr. The Ledger is given the
+// descriptors matching the tx first, if any.
+func SignTx(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Address, tx types.Transaction, descriptors *ledger.Descriptors, max int) (types.Transaction, error) {
 	var signed types.Transaction
 	wallets, err := GetWallets(term, walletType)
 	if err != nil {
 		return nil, err
 	}
-	hww, acc, _ := FindOneFromWallets(term, wallets, fromAddr, DefaultHDPaths, max)
+	hww, acc := findAccount(term, wallets, fromAddr, max)
 	if acc == (accounts.Account{}) {
 		return nil, errors.New(fmt.Sprintf("No account found for address: %s\n", fromAddr))
 	}
@@ -85,6 +91,9 @@ func SignTx(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Addr
 	if err != nil {
 		return nil, err
 	}
+	if err := provideDescriptors(hww, descriptors, tx); err != nil {
+		return nil, err
+	}
 	var addr common.Address
 	addr, signed, err = hww.SignTx(path, tx, tx.GetChainID())
 	if err != nil {
@@ -96,12 +105,22 @@ func SignTx(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Addr
 	return signed, nil
 }
 
+// provideDescriptors sends the token and plugin descriptors of the tx to a
+// Ledger. Other wallets do not use them.
+func provideDescriptors(hww hwcommon.HWWallet, descriptors *ledger.Descriptors, tx types.Transaction) error {
+	device, ok := hww.(ledger.Device)
+	if !ok || descriptors == nil {
+		return nil
+	}
+	return device.ProvideTxDescriptors(descriptors, tx, tx.GetChainID())
+}
+
 func SignMsg(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Address, msg []byte, max int) ([]byte, error) {
 	wallets, err := GetWallets(term, walletType)
 	if err != nil {
 		return nil, err
 	}
-	hww, acc, _ := FindOneFromWallets(term, wallets, fromAddr, DefaultHDPaths, max)
+	hww, acc := findAccount(term, wallets, fromAddr, max)
 	if acc == (accounts.Account{}) {
 		return nil, errors.New(fmt.Sprintf("No account found for address: %s\n", fromAddr))
 	}
@@ -120,12 +139,12 @@ func SignMsg(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Add
 	return sig, nil
 }
 
-func Encrypt(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Address, key []byte, data []byte, max int) ([]byte, error) {
+func SignTypedMsg(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Address, domainHash []byte, messageHash []byte, max int) ([]byte, error) {
 	wallets, err := GetWallets(term, walletType)
 	if err != nil {
 		return nil, err
 	}
-	hww, acc, _ := FindOneFromWallets(term, wallets, fromAddr, DefaultHDPaths, max)
+	hww, acc := findAccount(term, wallets, fromAddr, max)
 	if acc == (accounts.Account{}) {
 		return nil, errors.New(fmt.Sprintf("No account found for address: %s\n", fromAddr))
 	}
@@ -134,32 +153,37 @@ func Encrypt(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Add
 	if err != nil {
 		return nil, err
 	}
-	encrypted, err := hww.Encrypt(path, string(key), data, true, true)
+	addr, sig, err := hww.SignTypedMessage(path, domainHash, messageHash)
 	if err != nil {
 		return nil, err
 	}
-	return encrypted, nil
+	if addr != acc.Address {
+		return nil, errors.New("Signed typed data sender address != provided derivation path address!")
+	}
+	return sig, nil
 }
 
-func Decrypt(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Address, key []byte, data []byte, max int) ([]byte, error) {
-	wallets, err := GetWallets(term, walletType)
+// Encrypt encrypts data with the key named key of an account given by hdpath
+// or looked up by fromAddr. Returns the account to record its path.
+func Encrypt(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Address, hdpath string, key string, data []byte, opts hwcommon.CipherOptions, max int) (accounts.Account, []byte, error) {
+	hww, acc, path, err := resolveAccount(term, walletType, fromAddr, hdpath, max)
 	if err != nil {
-		return nil, err
-	}
-	hww, acc, _ := FindOneFromWallets(term, wallets, fromAddr, DefaultHDPaths, max)
-	if acc == (accounts.Account{}) {
-		return nil, errors.New(fmt.Sprintf("No account found for address: %s\n", fromAddr))
+		return accounts.Account{}, nil, err
 	}
-	term.Logf("Found account: %v, path: %s ...\n", acc.Address, acc.URL.Path)
-	path, err := accounts.ParseDerivationPath(acc.URL.Path)
+	encrypted, err := hww.Encrypt(path, key, data, opts)
 	if err != nil {
-		return nil, err
+		return accounts.Account{}, nil, err
 	}
-	decrypted, err := hww.Decrypt(path, string(key), data, true, true)
+	return acc, encrypted, nil
+}
+
+// Decrypt decrypts data encrypted with the same account, key name and options
+func Decrypt(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Address, hdpath string, key string, data []byte, opts hwcommon.CipherOptions, max int) ([]byte, error) {
+	hww, _, path, err := resolveAccount(term, walletType, fromAddr, hdpath, max)
 	if err != nil {
 		return nil, err
 	}
-	return decrypted, nil
+	return hww.Decrypt(path, key, data, opts)
 }
 
 func FindOneFromWallets(term ui.Screen, wallets []hwcommon.HWWallet, fromAddr common.Address, defaultHDPaths []string, max int) (hwcommon.HWWallet, accounts.Account, error) {
@@ -243,3 +267,72 @@ func Accounts(wallet hwcommon.HWWallet, defaultHDPaths []string, max int) ([]acc
 	}
 	return accs, nil
 }
+
+// VerifyAddress shows the address of an account on the device screen and waits
+// for the user to confirm it. The account is given by hdpath or looked up by
+// fromAddr. Fails if the user rejects it or the device shows another address.
+func VerifyAddress(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Address, hdpath string, max int) (accounts.Account, error) {
+	hww, acc, path, err := resolveAccount(term, walletType, fromAddr, hdpath, max)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	addr, err := hww.ShowAddress(path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	if acc.Address != (common.Address{}) && addr != acc.Address {
+		return accounts.Account{}, errors.New(fmt.Sprintf("Device address %s != expected address %s!", addr, acc.Address))
+	}
+	acc.Address = addr
+	return acc, nil
+}
+
+// VerifyMsg verifies a personal message signature of address on the first
+// wallet's trusted screen
+func VerifyMsg(term ui.Screen, walletType hwcommon.WalletType, address common.Address, signature []byte, msg []byte) error {
+	wallets, err := GetWallets(term, walletType)
+	if err != nil {
+		return err
+	}
+	if len(wallets) == 0 {
+		return errors.New("No hardware wallets found")
+	}
+	return wallets[0].VerifyMessage(address, signature, msg)
+}
+
+// resolveAccount returns the wallet and account of hdpath, or finds the account
+// of fromAddr if hdpath is empty. If both are given the address of hdpath must
+// be fromAddr.
+func resolveAccount(term ui.Screen, walletType hwcommon.WalletType, fromAddr common.Address, hdpath string, max int) (hwcommon.HWWallet, accounts.Account, accounts.DerivationPath, error) {
+	wallets, err := GetWallets(term, walletType)
+	if err != nil {
+		return nil, accounts.Account{}, nil, err
+	}
+	if len(wallets) == 0 {
+		return nil, accounts.Account{}, nil, errors.New("No hardware wallets found")
+	}
+	hww := wallets[0]
+	acc := accounts.Account{Address: fromAddr, URL: accounts.URL{Scheme: hww.Scheme(), Path: hdpath}}
+	if hdpath == "" {
+		hww, acc = findAccount(term, wallets, fromAddr, max)
+		if acc == (accounts.Account{}) {
+			return nil, accounts.Account{}, nil, errors.New(fmt.Sprintf("No account found for address: %s\n", fromAddr))
+		}
+		term.Logf("Found account: %v, path: %s ...\n", acc.Address, acc.URL.Path)
+	}
+	path, err := accounts.ParseDerivationPath(acc.URL.Path)
+	if err != nil {
+		return nil, accounts.Account{}, nil, err
+	}
+	if hdpath != "" {
+		addr, err := hww.Derive(path)
+		if err != nil {
+			return nil, accounts.Account{}, nil, err
+		}
+		if fromAddr != (common.Address{}) && addr != fromAddr {
+			return nil, accounts.Account{}, nil, errors.New(fmt.Sprintf("Address %s of hd path %s != expected address %s!", addr, hdpath, fromAddr))
+		}
+		acc.Address = addr
+	}
+	return hww, acc, path, nil
+}
@@ -0,0 +1,86 @@
+package hwwallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jaanek/jethwallet/accounts"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/ledger"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+type sessionAccount struct {
+	wallet hwcommon.HWWallet
+	path   accounts.DerivationPath
+}
+
+// Session signs many txs with the hw wallets opened once. The derivation path
+// of each account is looked up only on first use.
+type Session struct {
+	term     ui.Screen
+	wallets  []hwcommon.HWWallet
+	max      int
+	accounts map[common.Address]sessionAccount
+
+	descriptors *ledger.Descriptors
+}
+
+func NewSession(term ui.Screen, walletType hwcommon.WalletType, max int) (*Session, error) {
+	wallets, err := GetWallets(term, walletType)
+	if err != nil {
+		return nil, err
+	}
+	if len(wallets) == 0 {
+		return nil, errors.New("No hardware wallets found")
+	}
+	return &Session{
+		term:     term,
+		wallets:  wallets,
+		max:      max,
+		accounts: make(map[common.Address]sessionAccount),
+	}, nil
+}
+
+// SetDescriptors sets the token and plugin descriptors given to a Ledger
+// before signing each tx
+func (s *Session) SetDescriptors(descriptors *ledger.Descriptors) {
+	s.descriptors = descriptors
+}
+
+func (s *Session) SignTx(fromAddr common.Address, tx types.Transaction) (types.Transaction, error) {
+	acc, err := s.account(fromAddr)
+	if err != nil {
+		return nil, err
+	}
+	if err := provideDescriptors(acc.wallet, s.descriptors, tx); err != nil {
+		return nil, err
+	}
+	addr, signed, err := acc.wallet.SignTx(acc.path, tx, tx.GetChainID())
+	if err != nil {
+		return nil, err
+	}
+	if addr != fromAddr {
+		return nil, errors.New("Signed tx sender address != provided derivation path address!")
+	}
+	return signed, nil
+}
+
+func (s *Session) account(fromAddr common.Address) (sessionAccount, error) {
+	if acc, ok := s.accounts[fromAddr]; ok {
+		return acc, nil
+	}
+	hww, acc := findAccount(s.term, s.wallets, fromAddr, s.max)
+	if acc == (accounts.Account{}) {
+		return sessionAccount{}, errors.New(fmt.Sprintf("No account found for address: %s\n", fromAddr))
+	}
+	s.term.Logf("Found account: %v, path: %s ...\n", acc.Address, acc.URL.Path)
+	path, err := accounts.ParseDerivationPath(acc.URL.Path)
+	if err != nil {
+		return sessionAccount{}, err
+	}
+	s.accounts[fromAddr] = sessionAccount{wallet: hww, path: path}
+	return s.accounts[fromAddr], nil
+}
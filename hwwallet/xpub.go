@@ -0,0 +1,87 @@
+package hwwallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jaanek/jethwallet/accounts"
+	"github.com/jaanek/jethwallet/hdkey"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/ui"
+)
+
+// DefaultXPubPath is the parent path of bip44 accounts m/44'/60'/0'/0/N.
+// Ledger legacy accounts m/44'/60'/0'/N are children of m/44'/60'/0'. Ledger
+// Live paths (m/44'/60'/N'/0/0) are hardened per account and can not be
+// derived from an extended public key.
+const DefaultXPubPath = "m/44'/60'/0'/0"
+
+// XPub fetches the extended public key of hdpath from the first wallet
+func XPub(term ui.Screen, walletType hwcommon.WalletType, hdpath string) (*hdkey.ExtendedKey, error) {
+	path, err := accounts.ParseDerivationPath(hdpath)
+	if err != nil {
+		return nil, err
+	}
+	wallets, err := GetWallets(term, walletType)
+	if err != nil {
+		return nil, err
+	}
+	if len(wallets) == 0 {
+		return nil, errors.New("No hardware wallets found")
+	}
+	term.Logf("Wallet status: %s\n", wallets[0].Status())
+	return wallets[0].ExtendedPublicKey(path)
+}
+
+// CheckXPubPath checks hdpath against the depth and child number of key, as
+// the path of an external extended public key can not be read from the key
+func CheckXPubPath(key *hdkey.ExtendedKey, hdpath string) error {
+	path, err := accounts.ParseDerivationPath(hdpath)
+	if err != nil {
+		return err
+	}
+	if len(path) != int(key.Depth) || path[len(path)-1] != key.ChildNum {
+		return fmt.Errorf("xpub path %s does not match the extended public key at depth %d and child %d", hdpath, key.Depth, key.ChildNum)
+	}
+	return nil
+}
+
+// WatchAccounts derives the first max+1 child accounts of key offline. The
+// basePath is the path of key used to report the child paths, empty omits
+// them.
+func WatchAccounts(key *hdkey.ExtendedKey, basePath string, max int) ([]accounts.Account, error) {
+	accs := []accounts.Account{}
+	for i := 0; i <= max; i++ {
+		child, err := key.Child(uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		addr, err := child.Address()
+		if err != nil {
+			return nil, err
+		}
+		acc := accounts.Account{Address: addr, URL: accounts.URL{Scheme: "xpub"}}
+		if basePath != "" {
+			acc.URL.Path = fmt.Sprintf("%s/%d", basePath, i)
+		}
+		accs = append(accs, acc)
+	}
+	return accs, nil
+}
+
+// ListWatchAccounts lists the child accounts of an extended public key
+func ListWatchAccounts(term ui.Screen, key *hdkey.ExtendedKey, basePath string, max int, verbose bool) error {
+	accs, err := WatchAccounts(key, basePath, max)
+	if err != nil {
+		return err
+	}
+	for _, acc := range accs {
+		result := &accounts.AccountOutput{Address: acc.Address.Hex(), Path: acc.URL.Path}
+		if verbose && acc.URL.Path != "" {
+			term.Result(fmt.Sprintf("%s hd-path-%s\n", acc.Address.Hex(), acc.URL.Path), result)
+		} else {
+			term.Result(fmt.Sprintf("%s\n", acc.Address.Hex()), result)
+		}
+	}
+	return nil
+}
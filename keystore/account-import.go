@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/jaanek/jethwallet/accounts"
 	"github.com/jaanek/jethwallet/ui"
 	"github.com/ledgerwatch/erigon/crypto"
 )
@@ -32,5 +33,6 @@ func ImportKey(term ui.Screen, keystorePath string) error {
 		return fmt.Errorf("failed to import private key to keystore: %w", err)
 	}
 	term.Logf("New account created! Address: %s, path: %v\n", acc.Address, acc.URL.Path)
+	term.Result("", &accounts.AccountOutput{Address: acc.Address.Hex(), Path: acc.URL.Path})
 	return nil
 }
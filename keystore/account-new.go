@@ -3,6 +3,7 @@ package keystore
 import (
 	"errors"
 
+	"github.com/jaanek/jethwallet/accounts"
 	"github.com/jaanek/jethwallet/ui"
 )
 
@@ -21,5 +22,6 @@ func NewAccount(term ui.Screen, keystorePath string) error {
 		return err
 	}
 	term.Logf("New account created! Address: %s, path: %v\n", acc.Address, acc.URL.Path)
+	term.Result("", &accounts.AccountOutput{Address: acc.Address.Hex(), Path: acc.URL.Path})
 	return nil
 }
@@ -3,21 +3,23 @@ package keystore
 import (
 	"fmt"
 
+	"github.com/jaanek/jethwallet/accounts"
 	"github.com/jaanek/jethwallet/ui"
 )
 
 func ListAccounts(term ui.Screen, keystorePath string, verbose bool) error {
 	ks := NewKeyStore(term, keystorePath)
-	accounts, err := ks.Accounts()
+	accs, err := ks.Accounts()
 	if err != nil {
 		return err
 	}
-	term.Logf("Found %d account(s)\n", len(accounts))
-	for _, acc := range accounts {
+	term.Logf("Found %d account(s)\n", len(accs))
+	for _, acc := range accs {
+		result := &accounts.AccountOutput{Address: acc.Address.Hex(), Path: acc.URL.Path}
 		if verbose {
-			term.Output(fmt.Sprintf("%s path: %s\n", acc.Address, acc.URL.Path))
+			term.Result(fmt.Sprintf("%s path: %s\n", acc.Address, acc.URL.Path), result)
 		} else {
-			term.Output(fmt.Sprintf("%s\n", acc.Address))
+			term.Result(fmt.Sprintf("%s\n", acc.Address), result)
 		}
 	}
 	return nil
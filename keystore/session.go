@@ -0,0 +1,62 @@
+package keystore
+
+import (
+	"fmt"
+
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// Session signs many txs while asking the passphrase of each account only
+// once. Decrypted keys are kept in memory until Close.
+type Session struct {
+	term ui.Screen
+	ks   *KeyStore
+	keys map[common.Address]*Key
+}
+
+func NewSession(term ui.Screen, keystorePath string) *Session {
+	return &Session{
+		term: term,
+		ks:   NewKeyStore(term, keystorePath),
+		keys: make(map[common.Address]*Key),
+	}
+}
+
+func (s *Session) SignTx(fromAddr common.Address, tx types.Transaction) (types.Transaction, error) {
+	key, err := s.unlock(fromAddr)
+	if err != nil {
+		return nil, err
+	}
+	return s.ks.SignTx(key.PrivateKey, tx, tx.GetChainID())
+}
+
+// Close zeroes all decrypted keys
+func (s *Session) Close() {
+	for addr, key := range s.keys {
+		ZeroKey(key.PrivateKey)
+		delete(s.keys, addr)
+	}
+}
+
+func (s *Session) unlock(fromAddr common.Address) (*Key, error) {
+	if key, ok := s.keys[fromAddr]; ok {
+		return key, nil
+	}
+	acc, err := s.ks.FindOne(fromAddr)
+	if err != nil {
+		return nil, err
+	}
+	s.term.Print(fmt.Sprintf("*** Enter passphrase (not echoed) account: %v ...", acc.Address))
+	passphrase, err := s.term.ReadPassword()
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.ks.GetDecryptedKey(acc, string(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	s.keys[fromAddr] = key
+	return key, nil
+}
@@ -0,0 +1,247 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/holiman/uint256"
+	"github.com/jaanek/jethwallet/accounts"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// maxProvidedTokens is the number of token descriptors the Ethereum app keeps
+// for a single transaction
+const maxProvidedTokens = 2
+
+// Device is a Ledger wallet able to display token and contract call details
+// from signed descriptors
+type Device interface {
+	hwcommon.HWWallet
+	ProvideTokenInfo(token TokenInfo) error
+	SetExternalPlugin(plugin PluginInfo) error
+	ProvideTxDescriptors(descriptors *Descriptors, tx types.Transaction, chainID *uint256.Int) error
+}
+
+// TokenInfo is an ERC-20 token descriptor signed by Ledger
+type TokenInfo struct {
+	Ticker    string         `json:"ticker"`
+	Address   common.Address `json:"address"`
+	Decimals  uint32         `json:"decimals"`
+	ChainID   uint64         `json:"chainId"`
+	Signature hexutil.Bytes  `json:"signature"`
+}
+
+// PluginInfo is an external plugin descriptor signed by Ledger. It names the
+// app plugin that displays calls of a contract method.
+type PluginInfo struct {
+	Name      string         `json:"name"`
+	Address   common.Address `json:"address"`
+	Selector  hexutil.Bytes  `json:"selector"`
+	Signature hexutil.Bytes  `json:"signature"`
+}
+
+// Descriptors are the signed token and plugin descriptors read from a local
+// file like:
+//
+//   {
+//     "tokens": [{"ticker": "USDC", "address": "0x..", "decimals": 6, "chainId": 1, "signature": "0x.."}],
+//     "plugins": [{"name": "Paraswap", "address": "0x..", "selector": "0x..", "signature": "0x.."}]
+//   }
+type Descriptors struct {
+	Tokens  []TokenInfo  `json:"tokens"`
+	Plugins []PluginInfo `json:"plugins"`
+}
+
+// LoadDescriptors reads the signed descriptors from a json file
+func LoadDescriptors(path string) (*Descriptors, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var descriptors Descriptors
+	if err := json.Unmarshal(data, &descriptors); err != nil {
+		return nil, fmt.Errorf("invalid ledger descriptors %s: %w", path, err)
+	}
+	for _, plugin := range descriptors.Plugins {
+		if len(plugin.Selector) != 4 {
+			return nil, fmt.Errorf("invalid ledger descriptors %s: plugin %s selector must be 4 bytes", path, plugin.Name)
+		}
+	}
+	return &descriptors, nil
+}
+
+// ForTx returns the plugin of the called contract method and the tokens of
+// the called contract or referenced by the call args on the chain
+func (d *Descriptors) ForTx(tx types.Transaction, chainID *uint256.Int) (*PluginInfo, []TokenInfo) {
+	to := tx.GetTo()
+	if to == nil {
+		return nil, nil
+	}
+	data := tx.GetData()
+	var plugin *PluginInfo
+	if len(data) >= 4 {
+		for i := range d.Plugins {
+			if d.Plugins[i].Address == *to && bytes.Equal(d.Plugins[i].Selector, data[:4]) {
+				plugin = &d.Plugins[i]
+				break
+			}
+		}
+	}
+	var tokens []TokenInfo
+	if token, ok := d.token(*to, chainID); ok {
+		tokens = append(tokens, token)
+	}
+	// address args are abi encoded as 32 bytes words with 12 leading zeros
+	for i := 4; i+32 <= len(data) && len(tokens) < maxProvidedTokens; i += 32 {
+		word := data[i : i+32]
+		if !bytes.Equal(word[:12], make([]byte, 12)) {
+			continue
+		}
+		token, ok := d.token(common.BytesToAddress(word[12:]), chainID)
+		if ok && !containsToken(tokens, token) {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) > maxProvidedTokens {
+		tokens = tokens[:maxProvidedTokens]
+	}
+	return plugin, tokens
+}
+
+func (d *Descriptors) token(address common.Address, chainID *uint256.Int) (TokenInfo, bool) {
+	for _, token := range d.Tokens {
+		if token.Address == address && chainID.IsUint64() && token.ChainID == chainID.Uint64() {
+			return token, true
+		}
+	}
+	return TokenInfo{}, false
+}
+
+func containsToken(tokens []TokenInfo, token TokenInfo) bool {
+	for _, t := range tokens {
+		if t.Address == token.Address {
+			return true
+		}
+	}
+	return false
+}
+
+// ProvideTxDescriptors sends the descriptors matching the tx to the Ledger, so
+// the following SignTx displays token amounts and contract call details
+// instead of raw data.
+func (w *ledgerWallet) ProvideTxDescriptors(descriptors *Descriptors, tx types.Transaction, chainID *uint256.Int) error {
+	plugin, tokens := descriptors.ForTx(tx, chainID)
+	if plugin != nil {
+		if err := w.SetExternalPlugin(*plugin); err != nil {
+			return err
+		}
+		w.ui.Logf("Provided ledger plugin: %s\n", plugin.Name)
+	}
+	for _, token := range tokens {
+		if err := w.ProvideTokenInfo(token); err != nil {
+			return err
+		}
+		w.ui.Logf("Provided ledger token: %s %s\n", token.Ticker, token.Address.Hex())
+	}
+	return nil
+}
+
+// ProvideTokenInfo sends a signed ERC-20 token descriptor to the Ledger.
+//
+// The token information protocol is defined as follows:
+//
+//   CLA | INS | P1 | P2 | Lc  | Le
+//   ----+-----+----+----+-----+---
+//    E0 | 0A  | 00 | 00 | var | 00
+//
+// Where the input data is:
+//
+//   Description                 | Length
+//   ----------------------------+----------
+//   Ticker length               | 1 byte
+//   Ticker                      | arbitrary
+//   Contract address            | 20 bytes
+//   Decimals (big endian)       | 4 bytes
+//   Chain ID (big endian)       | 4 bytes
+//   Token information signature | arbitrary
+//
+// And the output data of newer apps is:
+//
+//   Description                 | Length
+//   ----------------------------+----------
+//   Token index                 | 1 byte
+func (w *ledgerWallet) ProvideTokenInfo(token TokenInfo) error {
+	if w.offline() {
+		return accounts.ErrWalletClosed
+	}
+	if len(token.Ticker) == 0 || len(token.Ticker) > math.MaxUint8 {
+		return fmt.Errorf("ledger: invalid token ticker %q", token.Ticker)
+	}
+	if token.ChainID > math.MaxUint32 {
+		return fmt.Errorf("ledger: token %s chain id %d does not fit into 32 bits", token.Ticker, token.ChainID)
+	}
+	payload := make([]byte, 0, 1+len(token.Ticker)+common.AddressLength+8+len(token.Signature))
+	payload = append(payload, byte(len(token.Ticker)))
+	payload = append(payload, token.Ticker...)
+	payload = append(payload, token.Address.Bytes()...)
+	payload = append(payload, uint32Bytes(token.Decimals)...)
+	payload = append(payload, uint32Bytes(uint32(token.ChainID))...)
+	payload = append(payload, token.Signature...)
+	if _, err := w.rawCall(ledgerOpProvideTokenInfo, 0, 0, payload); err != nil {
+		return fmt.Errorf("provide token %s: %w", token.Ticker, err)
+	}
+	return nil
+}
+
+// SetExternalPlugin sends a signed plugin descriptor to the Ledger. The app
+// fails if the plugin is not installed on the device.
+//
+// The external plugin protocol is defined as follows:
+//
+//   CLA | INS | P1 | P2 | Lc  | Le
+//   ----+-----+----+----+-----+---
+//    E0 | 12  | 00 | 00 | var | 00
+//
+// Where the input data is:
+//
+//   Description                  | Length
+//   -----------------------------+----------
+//   Plugin name length           | 1 byte
+//   Plugin name                  | arbitrary
+//   Contract address             | 20 bytes
+//   Method selector              | 4 bytes
+//   Plugin information signature | arbitrary
+func (w *ledgerWallet) SetExternalPlugin(plugin PluginInfo) error {
+	if w.offline() {
+		return accounts.ErrWalletClosed
+	}
+	if len(plugin.Name) == 0 || len(plugin.Name) > math.MaxUint8 {
+		return fmt.Errorf("ledger: invalid plugin name %q", plugin.Name)
+	}
+	if len(plugin.Selector) != 4 {
+		return fmt.Errorf("ledger: plugin %s selector must be 4 bytes", plugin.Name)
+	}
+	payload := make([]byte, 0, 1+len(plugin.Name)+common.AddressLength+4+len(plugin.Signature))
+	payload = append(payload, byte(len(plugin.Name)))
+	payload = append(payload, plugin.Name...)
+	payload = append(payload, plugin.Address.Bytes()...)
+	payload = append(payload, plugin.Selector...)
+	payload = append(payload, plugin.Signature...)
+	if _, err := w.rawCall(ledgerOpSetExternalPlugin, 0, 0, payload); err != nil {
+		return fmt.Errorf("set plugin %s: %w", plugin.Name, err)
+	}
+	return nil
+}
+
+func uint32Bytes(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
@@ -0,0 +1,167 @@
+package ledger
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+var (
+	usdc   = common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	dai    = common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	weth   = common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	router = common.HexToAddress("0xdef171fe48cf0115b1d80b88dc8eab59176fee57")
+
+	transferSelector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+	swapSelector     = []byte{0x38, 0xed, 0x17, 0x39}
+)
+
+func testDescriptors() *Descriptors {
+	return &Descriptors{
+		Tokens: []TokenInfo{
+			{Ticker: "USDC", Address: usdc, Decimals: 6, ChainID: 1, Signature: []byte{1}},
+			{Ticker: "DAI", Address: dai, Decimals: 18, ChainID: 1, Signature: []byte{2}},
+			{Ticker: "WETH", Address: weth, Decimals: 18, ChainID: 1, Signature: []byte{3}},
+		},
+		Plugins: []PluginInfo{
+			{Name: "Paraswap", Address: router, Selector: swapSelector, Signature: []byte{4}},
+		},
+	}
+}
+
+// callData returns the abi encoded call of selector with address and
+// amount args
+func callData(selector []byte, args ...interface{}) []byte {
+	data := append([]byte{}, selector...)
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case common.Address:
+			data = append(data, common.LeftPadBytes(v.Bytes(), 32)...)
+		case uint64:
+			data = append(data, common.LeftPadBytes(uint256.NewInt(v).Bytes(), 32)...)
+		}
+	}
+	return data
+}
+
+func tickers(tokens []TokenInfo) []string {
+	out := []string{}
+	for _, token := range tokens {
+		out = append(out, token.Ticker)
+	}
+	return out
+}
+
+func TestDescriptorsForTx(t *testing.T) {
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tests := []struct {
+		name    string
+		tx      types.Transaction
+		chainID uint64
+		plugin  string
+		tokens  []string
+	}{
+		{
+			name:    "transfer to a token",
+			tx:      types.NewTransaction(0, usdc, uint256.NewInt(0), 60000, uint256.NewInt(1), callData(transferSelector, recipient, uint64(1000000))),
+			chainID: 1,
+			tokens:  []string{"USDC"},
+		},
+		{
+			name:    "swap referencing two tokens",
+			tx:      types.NewTransaction(0, router, uint256.NewInt(0), 300000, uint256.NewInt(1), callData(swapSelector, uint64(1000), uint64(900), dai, usdc, recipient)),
+			chainID: 1,
+			plugin:  "Paraswap",
+			tokens:  []string{"DAI", "USDC"},
+		},
+		{
+			name:    "swap referencing three tokens is capped",
+			tx:      types.NewTransaction(0, router, uint256.NewInt(0), 300000, uint256.NewInt(1), callData(swapSelector, dai, usdc, weth)),
+			chainID: 1,
+			plugin:  "Paraswap",
+			tokens:  []string{"DAI", "USDC"},
+		},
+		{
+			name:    "token referenced twice",
+			tx:      types.NewTransaction(0, usdc, uint256.NewInt(0), 60000, uint256.NewInt(1), callData(transferSelector, usdc, uint64(1))),
+			chainID: 1,
+			tokens:  []string{"USDC"},
+		},
+		{
+			name:    "other method of the plugin contract",
+			tx:      types.NewTransaction(0, router, uint256.NewInt(0), 60000, uint256.NewInt(1), callData(transferSelector, dai)),
+			chainID: 1,
+			tokens:  []string{"DAI"},
+		},
+		{
+			name:    "chain id mismatch",
+			tx:      types.NewTransaction(0, usdc, uint256.NewInt(0), 60000, uint256.NewInt(1), callData(transferSelector, dai, uint64(1))),
+			chainID: 137,
+			tokens:  []string{},
+		},
+		{
+			name:    "contract creation",
+			tx:      types.NewContractCreation(0, uint256.NewInt(0), 1000000, uint256.NewInt(1), callData(transferSelector, usdc)),
+			chainID: 1,
+			tokens:  []string{},
+		},
+	}
+	for _, test := range tests {
+		plugin, tokens := testDescriptors().ForTx(test.tx, uint256.NewInt(test.chainID))
+		pluginName := ""
+		if plugin != nil {
+			pluginName = plugin.Name
+		}
+		if pluginName != test.plugin {
+			t.Errorf("%s: plugin %q, want %q", test.name, pluginName, test.plugin)
+		}
+		if got := tickers(tokens); strings.Join(got, ",") != strings.Join(test.tokens, ",") {
+			t.Errorf("%s: tokens %v, want %v", test.name, got, test.tokens)
+		}
+	}
+}
+
+func TestLoadDescriptors(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		valid bool
+	}{
+		{
+			name:  "valid",
+			json:  `{"tokens":[{"ticker":"USDC","address":"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48","decimals":6,"chainId":1,"signature":"0x01"}],"plugins":[{"name":"Paraswap","address":"0xdef171fe48cf0115b1d80b88dc8eab59176fee57","selector":"0x38ed1739","signature":"0x04"}]}`,
+			valid: true,
+		},
+		{
+			name: "bad selector length",
+			json: `{"plugins":[{"name":"Paraswap","address":"0xdef171fe48cf0115b1d80b88dc8eab59176fee57","selector":"0x38ed17","signature":"0x04"}]}`,
+		},
+		{
+			name: "invalid json",
+			json: `{"tokens":`,
+		},
+	}
+	dir := t.TempDir()
+	for i, test := range tests {
+		path := filepath.Join(dir, string(rune('a'+i))+".json")
+		if err := ioutil.WriteFile(path, []byte(test.json), 0600); err != nil {
+			t.Fatal(err)
+		}
+		descriptors, err := LoadDescriptors(path)
+		switch {
+		case !test.valid:
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case len(descriptors.Tokens) != 1 || descriptors.Tokens[0].Address != usdc || len(descriptors.Plugins) != 1:
+			t.Errorf("%s: unexpected descriptors %+v", test.name, descriptors)
+		}
+	}
+}
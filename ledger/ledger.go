@@ -10,8 +10,10 @@ import (
 
 	"github.com/holiman/uint256"
 	"github.com/jaanek/jethwallet/accounts"
+	"github.com/jaanek/jethwallet/hdkey"
 	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
 	"github.com/jaanek/jethwallet/ui"
+	"github.com/jaanek/jethwallet/wallet"
 	"github.com/karalabe/usb"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core/types"
@@ -58,16 +60,23 @@ type ledgerParam1 byte
 type ledgerParam2 byte
 
 const (
-	ledgerOpRetrieveAddress  ledgerOpcode = 0x02 // Returns the public key and Ethereum address for a given BIP 32 path
-	ledgerOpSignTransaction  ledgerOpcode = 0x04 // Signs an Ethereum transaction after having the user validate the parameters
-	ledgerOpGetConfiguration ledgerOpcode = 0x06 // Returns specific wallet application configuration
-	ledgerOpSignTypedMessage ledgerOpcode = 0x0c // Signs an Ethereum message following the EIP 712 specification
+	ledgerOpRetrieveAddress     ledgerOpcode = 0x02 // Returns the public key and Ethereum address for a given BIP 32 path
+	ledgerOpSignTransaction     ledgerOpcode = 0x04 // Signs an Ethereum transaction after having the user validate the parameters
+	ledgerOpGetConfiguration    ledgerOpcode = 0x06 // Returns specific wallet application configuration
+	ledgerOpSignPersonalMessage ledgerOpcode = 0x08 // Signs an Ethereum message following the personal_sign specification
+	ledgerOpSignTypedMessage    ledgerOpcode = 0x0c // Signs an Ethereum message following the EIP 712 specification
+	ledgerOpProvideTokenInfo    ledgerOpcode = 0x0a // Provides a signed ERC-20 token descriptor to display token amounts
+	ledgerOpSetExternalPlugin   ledgerOpcode = 0x12 // Provides a signed plugin descriptor to display a contract call
 
 	ledgerP1DirectlyFetchAddress    ledgerParam1 = 0x00 // Return address directly from the wallet
+	ledgerP1ConfirmFetchAddress     ledgerParam1 = 0x01 // Display address and confirm before returning
 	ledgerP1InitTypedMessageData    ledgerParam1 = 0x00 // First chunk of Typed Message data
+	ledgerP1InitPersonalMessageData ledgerParam1 = 0x00 // First chunk of Personal Message data
+	ledgerP1ContPersonalMessageData ledgerParam1 = 0x80 // Subsequent chunk of Personal Message data
 	ledgerP1InitTransactionData     ledgerParam1 = 0x00 // First transaction data block for signing
 	ledgerP1ContTransactionData     ledgerParam1 = 0x80 // Subsequent transaction data block for signing
 	ledgerP2DiscardAddressChainCode ledgerParam2 = 0x00 // Do not return the chain code along with the address
+	ledgerP2ReturnAddressChainCode  ledgerParam2 = 0x01 // Return the chain code along with the address
 )
 
 // errLedgerReplyInvalidHeader is the error message returned by a Ledger data exchange
@@ -79,11 +88,21 @@ var errLedgerReplyInvalidHeader = errors.New("ledger: invalid reply header")
 // when a response does arrive, but it does not contain the expected data.
 var errLedgerInvalidVersionReply = errors.New("ledger: invalid version reply")
 
+// errLedgerUserRejected is the error message returned by a Ledger data exchange
+// if the user rejected the request on the device.
+var errLedgerUserRejected = errors.New("ledger: request rejected by the user")
+
+const (
+	ledgerStatusOK           = 0x9000 // Status word of a successful request
+	ledgerStatusUserRejected = 0x6985 // Status word of a request rejected by the user
+)
+
 type ledgerWallet struct {
-	ui      ui.Screen
-	device  usb.Device // USB device advertising itself as a hardware wallet
-	browser bool
-	version [3]byte
+	ui       ui.Screen
+	device   usb.Device // USB device advertising itself as a hardware wallet
+	browser  bool
+	version  [3]byte
+	baseAddr common.Address // address of the default base path, identifies the seed
 }
 
 func Wallets(term ui.Screen) ([]hwcommon.HWWallet, error) {
@@ -126,7 +145,7 @@ func Wallets(term ui.Screen) ([]hwcommon.HWWallet, error) {
 }
 
 func (w *ledgerWallet) init() error {
-	_, err := w.Derive(accounts.DefaultBaseDerivationPath)
+	baseAddr, err := w.Derive(accounts.DefaultBaseDerivationPath)
 	if err != nil {
 		// Ethereum app is not running or in browser mode, nothing more to do, return
 		if err == errLedgerReplyInvalidHeader {
@@ -135,6 +154,7 @@ func (w *ledgerWallet) init() error {
 		}
 		return nil
 	}
+	w.baseAddr = baseAddr
 	// Try to resolve the Ethereum app's version, will fail prior to v1.0.2
 	if w.version, err = w.ledgerVersion(); err != nil {
 		w.version = [3]byte{1, 0, 0} // Assume worst case, can't verify if v1.0.0 or v1.0.1
@@ -210,16 +230,210 @@ func (w *ledgerWallet) Label() string {
 	return fmt.Sprintf("%x", w.version)
 }
 
-func (w *ledgerWallet) Encrypt(path accounts.DerivationPath, key string, data []byte, askOnEncrypt, askOnDecrypt bool) ([]byte, error) {
+// DeviceID returns a fingerprint of the device seed (and passphrase) or empty
+// if the Ethereum app is not running. Ledger does not expose a device serial.
+func (w *ledgerWallet) DeviceID() string {
+	if w.baseAddr == (common.Address{}) {
+		return ""
+	}
+	return "ledger:" + w.baseAddr.Hex()
+}
+
+// ExtendedPublicKey returns the BIP32 extended public key of path, used to
+// derive the child addresses offline. The parent fingerprint needs the public
+// key of the parent path, so it takes two requests.
+func (w *ledgerWallet) ExtendedPublicKey(path accounts.DerivationPath) (*hdkey.ExtendedKey, error) {
+	pubKey, chainCode, err := w.PublicKey(path)
+	if err != nil {
+		return nil, err
+	}
+	var parentFP, childNum uint32
+	if len(path) > 0 {
+		parentPubKey, _, err := w.PublicKey(path[:len(path)-1])
+		if err != nil {
+			return nil, err
+		}
+		if parentFP, err = hdkey.PubKeyFingerprint(parentPubKey); err != nil {
+			return nil, err
+		}
+		childNum = path[len(path)-1]
+	}
+	return hdkey.NewExtendedKey(pubKey, chainCode, uint8(len(path)), parentFP, childNum)
+}
+
+func (w *ledgerWallet) VerifyMessage(address common.Address, signature []byte, msg []byte) error {
+	return accounts.ErrNotSupported
+}
+
+func (w *ledgerWallet) Encrypt(path accounts.DerivationPath, key string, data []byte, opts hwcommon.CipherOptions) ([]byte, error) {
 	return nil, accounts.ErrNotSupported
 }
 
-func (w *ledgerWallet) Decrypt(path accounts.DerivationPath, key string, data []byte, askOnEncrypt, askOnDecrypt bool) ([]byte, error) {
+func (w *ledgerWallet) Decrypt(path accounts.DerivationPath, key string, data []byte, opts hwcommon.CipherOptions) ([]byte, error) {
 	return nil, accounts.ErrNotSupported
 }
 
-func (w *ledgerWallet) SignMessage(path accounts.DerivationPath, msg []byte) (common.Address, []byte, error) {
-	return common.Address{}, nil, accounts.ErrNotSupported
+// SignMessage sends the message to the Ledger and waits for the user to
+// confirm or deny the signing. The device prefixes the message with
+// "\x19Ethereum Signed Message:\n" + length before hashing (personal_sign).
+//
+// The personal message signing protocol is defined as follows:
+//
+//   CLA | INS | P1 | P2 | Lc  | Le
+//   ----+-----+----+----+-----+---
+//    E0 | 08  | 00: first message data block
+//               80: subsequent message data block
+//                  | 00 | variable | variable
+//
+// Where the input for the first message block (first 255 bytes) is:
+//
+//   Description                                      | Length
+//   -------------------------------------------------+----------
+//   Number of BIP 32 derivations to perform (max 10) | 1 byte
+//   First derivation index (big endian)              | 4 bytes
+//   ...                                              | 4 bytes
+//   Last derivation index (big endian)               | 4 bytes
+//   Message length (big endian)                      | 4 bytes
+//   Message chunk                                    | arbitrary
+//
+// And the input for subsequent message blocks (first 255 bytes) are:
+//
+//   Description   | Length
+//   --------------+----------
+//   Message chunk | arbitrary
+//
+// And the output data is:
+//
+//   Description | Length
+//   ------------+---------
+//   signature V | 1 byte
+//   signature R | 32 bytes
+//   signature S | 32 bytes
+func (w *ledgerWallet) SignMessage(derivationPath accounts.DerivationPath, msg []byte) (common.Address, []byte, error) {
+	// If the Ethereum app doesn't run, abort
+	if w.offline() {
+		return common.Address{}, nil, accounts.ErrWalletClosed
+	}
+	// Flatten the derivation path into the Ledger request
+	path := make([]byte, 1+4*len(derivationPath))
+	path[0] = byte(len(derivationPath))
+	for i, component := range derivationPath {
+		binary.BigEndian.PutUint32(path[1+4*i:], component)
+	}
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(msg)))
+	payload := append(append(path, length...), msg...)
+
+	// Send the request and wait for the response
+	var (
+		op    = ledgerP1InitPersonalMessageData
+		reply []byte
+		err   error
+	)
+	for len(payload) > 0 {
+		// Calculate the size of the next data chunk
+		chunk := 255
+		if chunk > len(payload) {
+			chunk = len(payload)
+		}
+		// Send the chunk over, ensuring it's processed correctly
+		reply, err = w.rawCall(ledgerOpSignPersonalMessage, op, 0, payload[:chunk])
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		// Shift the payload and ensure subsequent chunks are marked as such
+		payload = payload[chunk:]
+		op = ledgerP1ContPersonalMessageData
+	}
+	// Extract the Ethereum signature and do a sanity validation
+	if len(reply) != crypto.SignatureLength {
+		return common.Address{}, nil, errors.New("reply lacks signature")
+	}
+	signature := append(reply[1:], reply[0])
+
+	// Recover the signer to let the caller verify it against the derivation path
+	hash := crypto.Keccak256(wallet.MessageWithEthPrefix(msg))
+	sig := make([]byte, len(signature))
+	copy(sig, signature)
+	sig[crypto.RecoveryIDOffset] -= 27
+	pubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), signature, nil
+}
+
+// SignTypedMessage sends the EIP-712 domain and message hashes to the Ledger
+// and waits for the user to confirm or deny the signing.
+//
+// The typed message signing protocol is defined as follows:
+//
+//   CLA | INS | P1 | P2 | Lc  | Le
+//   ----+-----+----+----+-----+---
+//    E0 | 0C  | 00 | 00 | variable | variable
+//
+// Where the input is:
+//
+//   Description                                      | Length
+//   -------------------------------------------------+----------
+//   Number of BIP 32 derivations to perform (max 10) | 1 byte
+//   First derivation index (big endian)              | 4 bytes
+//   ...                                              | 4 bytes
+//   Last derivation index (big endian)               | 4 bytes
+//   domain hash                                      | 32 bytes
+//   message hash                                     | 32 bytes
+//
+// And the output data is:
+//
+//   Description | Length
+//   ------------+---------
+//   signature V | 1 byte
+//   signature R | 32 bytes
+//   signature S | 32 bytes
+func (w *ledgerWallet) SignTypedMessage(derivationPath accounts.DerivationPath, domainHash []byte, messageHash []byte) (common.Address, []byte, error) {
+	// If the Ethereum app doesn't run, abort
+	if w.offline() {
+		return common.Address{}, nil, accounts.ErrWalletClosed
+	}
+	// Ensure the wallet is capable of signing the given transaction
+	if w.version[0] < 1 || (w.version[0] == 1 && w.version[1] < 5) {
+		//lint:ignore ST1005 brand name displayed on the console
+		return common.Address{}, nil, fmt.Errorf("Ledger version >= 1.5.0 required for EIP-712 signing (found version v%d.%d.%d)", w.version[0], w.version[1], w.version[2])
+	}
+	if len(domainHash) != 32 || len(messageHash) != 32 {
+		return common.Address{}, nil, errors.New("ledger: domain and message hashes must be 32 bytes")
+	}
+	// Flatten the derivation path into the Ledger request
+	path := make([]byte, 1+4*len(derivationPath))
+	path[0] = byte(len(derivationPath))
+	for i, component := range derivationPath {
+		binary.BigEndian.PutUint32(path[1+4*i:], component)
+	}
+	// Create the 712 message
+	payload := append(path, domainHash...)
+	payload = append(payload, messageHash...)
+
+	// Send the request and wait for the response
+	reply, err := w.rawCall(ledgerOpSignTypedMessage, ledgerP1InitTypedMessageData, 0, payload)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	// Extract the Ethereum signature and do a sanity validation
+	if len(reply) != crypto.SignatureLength {
+		return common.Address{}, nil, errors.New("reply lacks signature")
+	}
+	signature := append(reply[1:], reply[0])
+
+	// Recover the signer to let the caller verify it against the derivation path
+	hash := crypto.Keccak256([]byte("\x19\x01"), domainHash, messageHash)
+	sig := make([]byte, len(signature))
+	copy(sig, signature)
+	sig[crypto.RecoveryIDOffset] -= 27
+	pubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), signature, nil
 }
 
 // SignTx sends the transaction to the Ledger and
@@ -248,6 +462,7 @@ func (w *ledgerWallet) SignMessage(path accounts.DerivationPath, msg []byte) (co
 //   First derivation index (big endian)              | 4 bytes
 //   ...                                              | 4 bytes
 //   Last derivation index (big endian)               | 4 bytes
+//   Transaction type (EIP-2718 typed tx only)        | 1 byte
 //   RLP transaction chunk                            | arbitrary
 //
 // And the input for subsequent transaction blocks (first 255 bytes) are:
@@ -263,6 +478,8 @@ func (w *ledgerWallet) SignMessage(path accounts.DerivationPath, msg []byte) (co
 //   signature V | 1 byte
 //   signature R | 32 bytes
 //   signature S | 32 bytes
+//
+// Where signature V is the y parity (0 or 1) for typed transactions.
 func (w *ledgerWallet) SignTx(derivationPath accounts.DerivationPath, tx types.Transaction, chainID *uint256.Int) (common.Address, types.Transaction, error) {
 	// If the Ethereum app doesn't run, abort
 	if w.offline() {
@@ -273,6 +490,21 @@ func (w *ledgerWallet) SignTx(derivationPath accounts.DerivationPath, tx types.T
 		//lint:ignore ST1005 brand name displayed on the console
 		return common.Address{}, nil, fmt.Errorf("Ledger v%d.%d.%d doesn't support signing this transaction, please update to v1.0.3 at least", w.version[0], w.version[1], w.version[2])
 	}
+	if tx.Type() != types.LegacyTxType && (w.version[0] < 1 || (w.version[0] == 1 && w.version[1] < 9)) {
+		//lint:ignore ST1005 brand name displayed on the console
+		return common.Address{}, nil, fmt.Errorf("Ledger v%d.%d.%d doesn't support signing typed transactions, please update to v1.9.0 at least", w.version[0], w.version[1], w.version[2])
+	}
+
+	// The signature V of legacy txs is recovered against the address of the path
+	var (
+		address common.Address
+		err     error
+	)
+	if tx.Type() == types.LegacyTxType {
+		if address, err = w.Derive(derivationPath); err != nil {
+			return common.Address{}, nil, err
+		}
+	}
 
 	// All infos gathered and metadata checks out, request signing
 	// Flatten the derivation path into the Ledger request
@@ -281,19 +513,26 @@ func (w *ledgerWallet) SignTx(derivationPath accounts.DerivationPath, tx types.T
 	for i, component := range derivationPath {
 		binary.BigEndian.PutUint32(path[1+4*i:], component)
 	}
-	// Create the transaction RLP based on whether legacy or EIP155 signing was requested
-	var (
-		txrlp []byte
-		err   error
-	)
-	// var encoded bytes.Buffer
-	// err = signed.MarshalBinary(&encoded)
-	// if err != nil {
-	// 	return err
-	// }
-	// if txrlp, err = rlp.EncodeToBytes([]interface{}{tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), chainID, big.NewInt(0), big.NewInt(0)}); err != nil {
-	if txrlp, err = rlp.EncodeToBytes([]interface{}{tx.GetNonce(), tx.GetPrice(), tx.GetGas(), tx.GetTo(), tx.GetValue(), tx.GetData(), chainID.ToBig(), big.NewInt(0), big.NewInt(0)}); err != nil {
-		return common.Address{}, nil, err
+	// Create the transaction RLP based on the transaction type. Typed (EIP-2718)
+	// transactions are sent as the type byte followed by the RLP payload
+	var txrlp []byte
+	switch tx.Type() {
+	case types.LegacyTxType:
+		if txrlp, err = rlp.EncodeToBytes([]interface{}{tx.GetNonce(), tx.GetPrice(), tx.GetGas(), tx.GetTo(), tx.GetValue(), tx.GetData(), chainID.ToBig(), big.NewInt(0), big.NewInt(0)}); err != nil {
+			return common.Address{}, nil, err
+		}
+	case types.AccessListTxType:
+		if txrlp, err = rlp.EncodeToBytes([]interface{}{chainID.ToBig(), tx.GetNonce(), tx.GetPrice(), tx.GetGas(), tx.GetTo(), tx.GetValue(), tx.GetData(), tx.GetAccessList()}); err != nil {
+			return common.Address{}, nil, err
+		}
+		txrlp = append([]byte{tx.Type()}, txrlp...)
+	case types.DynamicFeeTxType:
+		if txrlp, err = rlp.EncodeToBytes([]interface{}{chainID.ToBig(), tx.GetNonce(), tx.GetTip(), tx.GetFeeCap(), tx.GetGas(), tx.GetTo(), tx.GetValue(), tx.GetData(), tx.GetAccessList()}); err != nil {
+			return common.Address{}, nil, err
+		}
+		txrlp = append([]byte{tx.Type()}, txrlp...)
+	default:
+		return common.Address{}, nil, fmt.Errorf("unsupported tx type %d", tx.Type())
 	}
 	payload := append(path, txrlp...)
 
@@ -323,20 +562,35 @@ func (w *ledgerWallet) SignTx(derivationPath accounts.DerivationPath, tx types.T
 	}
 	signature := append(reply[1:], reply[0])
 
-	// Create the correct signer and signature transform based on the chain ID
-	// signer := types.NewLondonSigner(&chainID)
+	// Create the correct signer and signature transform based on the chain ID.
+	// For typed transactions the Ledger replies with the raw parity (0 or 1) as
+	// V. For legacy transactions it replies with only the low byte of
+	// chainID*2+35+parity, which can not be reversed for chain ids above 109,
+	// so both parities are tried against the address of the path.
 	signer := types.LatestSignerForChainID(chainID.ToBig())
-	signature[64] -= byte(chainID.Uint64()*2 + 35)
-
-	signed, err := tx.WithSignature(*signer, signature)
-	if err != nil {
-		return common.Address{}, nil, err
+	if tx.Type() != types.LegacyTxType {
+		signed, err := tx.WithSignature(*signer, signature)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		sender, err := signed.Sender(*signer)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		return sender, signed, nil
 	}
-	sender, err := signed.Sender(*signer)
-	if err != nil {
-		return common.Address{}, nil, err
+	for _, parity := range []byte{0, 1} {
+		signature[64] = parity
+		signed, err := tx.WithSignature(*signer, signature)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		sender, err := signed.Sender(*signer)
+		if err == nil && sender == address {
+			return sender, signed, nil
+		}
 	}
-	return sender, signed, nil
+	return common.Address{}, nil, fmt.Errorf("ledger: signature does not recover to %s of path %s", address.Hex(), derivationPath)
 }
 
 // Derive retrieves the currently active Ethereum address from a Ledger
@@ -371,6 +625,34 @@ func (w *ledgerWallet) SignTx(derivationPath accounts.DerivationPath, tx types.T
 //   Ethereum address        | 40 bytes hex ascii
 //   Chain code if requested | 32 bytes
 func (w *ledgerWallet) Derive(derivationPath accounts.DerivationPath) (common.Address, error) {
+	_, address, _, err := w.retrieveAddress(derivationPath, ledgerP1DirectlyFetchAddress, ledgerP2DiscardAddressChainCode)
+	return address, err
+}
+
+// ShowAddress displays the address of the derivation path on the device and
+// returns it after the user has confirmed it.
+func (w *ledgerWallet) ShowAddress(derivationPath accounts.DerivationPath) (common.Address, error) {
+	w.ui.Print("*** NB! Confirm the address on your Ledger screen ...")
+	_, address, _, err := w.retrieveAddress(derivationPath, ledgerP1ConfirmFetchAddress, ledgerP2DiscardAddressChainCode)
+	return address, err
+}
+
+// PublicKey retrieves the uncompressed (65 bytes) public key and the chain
+// code of the specified derivation path.
+func (w *ledgerWallet) PublicKey(derivationPath accounts.DerivationPath) ([]byte, []byte, error) {
+	pubKey, _, chainCode, err := w.retrieveAddress(derivationPath, ledgerP1DirectlyFetchAddress, ledgerP2ReturnAddressChainCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(chainCode) != 32 {
+		return nil, nil, errors.New("reply lacks chain code entry")
+	}
+	return pubKey, chainCode, nil
+}
+
+// retrieveAddress sends the address derivation request described at Derive and
+// returns the public key, the address and the chain code if requested with p2.
+func (w *ledgerWallet) retrieveAddress(derivationPath accounts.DerivationPath, p1 ledgerParam1, p2 ledgerParam2) ([]byte, common.Address, []byte, error) {
 	// Flatten the derivation path into the Ledger request
 	path := make([]byte, 1+4*len(derivationPath))
 	path[0] = byte(len(derivationPath))
@@ -378,28 +660,35 @@ func (w *ledgerWallet) Derive(derivationPath accounts.DerivationPath) (common.Ad
 		binary.BigEndian.PutUint32(path[1+4*i:], component)
 	}
 	// Send the request and wait for the response
-	reply, err := w.rawCall(ledgerOpRetrieveAddress, ledgerP1DirectlyFetchAddress, ledgerP2DiscardAddressChainCode, path)
+	reply, err := w.rawCall(ledgerOpRetrieveAddress, p1, p2, path)
 	if err != nil {
-		return common.Address{}, err
+		return nil, common.Address{}, nil, err
 	}
-	// Discard the public key, we don't need that for now
+	// Extract the public key
 	if len(reply) < 1 || len(reply) < 1+int(reply[0]) {
-		return common.Address{}, errors.New("reply lacks public key entry")
+		return nil, common.Address{}, nil, errors.New("reply lacks public key entry")
 	}
+	pubKey := common.CopyBytes(reply[1 : 1+int(reply[0])])
 	reply = reply[1+int(reply[0]):]
 
 	// Extract the Ethereum hex address string
 	if len(reply) < 1 || len(reply) < 1+int(reply[0]) {
-		return common.Address{}, errors.New("reply lacks address entry")
+		return nil, common.Address{}, nil, errors.New("reply lacks address entry")
 	}
 	hexstr := reply[1 : 1+int(reply[0])]
+	reply = reply[1+int(reply[0]):]
 
-	// Decode the hex string into an Ethereum address and return
+	// Decode the hex string into an Ethereum address
 	var address common.Address
 	if _, err = hex.Decode(address[:], hexstr); err != nil {
-		return common.Address{}, err
+		return nil, common.Address{}, nil, err
+	}
+	// The chain code follows the address if requested
+	var chainCode []byte
+	if p2 == ledgerP2ReturnAddressChainCode && len(reply) >= 32 {
+		chainCode = common.CopyBytes(reply[:32])
 	}
-	return address, nil
+	return pubKey, address, chainCode, nil
 }
 
 //
@@ -500,5 +789,16 @@ func (w *ledgerWallet) rawCall(opcode ledgerOpcode, p1 ledgerParam1, p2 ledgerPa
 			break
 		}
 	}
+	if len(reply) < 2 {
+		return nil, errLedgerReplyInvalidHeader
+	}
+	// Check the status word trailing the reply
+	switch status := binary.BigEndian.Uint16(reply[len(reply)-2:]); status {
+	case ledgerStatusOK:
+	case ledgerStatusUserRejected:
+		return nil, errLedgerUserRejected
+	default:
+		return nil, fmt.Errorf("ledger: unexpected status word %#04x", status)
+	}
 	return reply[:len(reply)-2], nil
 }
@@ -20,31 +20,72 @@ import (
 )
 
 type StdInput struct {
-	RpcUrl         string `json:"rpcUrl"`
-	ChainId        string `json:"chainId"`
-	From           string `json:"from"`
-	To             string `json:"to"`
-	Value          string `json:"value"`
-	Data           string `json:"data"`
-	Method         string `json:"method"`
-	GasTip         string `json:"gasTip"`
-	GasPrice       string `json:"gasPrice"`
-	Gas            string `json:"gas"`
-	TxCount        string `json:"txCount"`
-	TxCountPending string `json:"txCountPending"`
-	Balance        string `json:"balance"`
+	RpcUrl         string          `json:"rpcUrl"`
+	ChainId        string          `json:"chainId"`
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	Value          string          `json:"value"`
+	Data           string          `json:"data"`
+	Method         string          `json:"method"`
+	GasTip         string          `json:"gasTip"`
+	GasPrice       string          `json:"gasPrice"`
+	Gas            string          `json:"gas"`
+	TxCount        string          `json:"txCount"`
+	TxCountPending string          `json:"txCountPending"`
+	Balance        string          `json:"balance"`
+	AccessList     json.RawMessage `json:"accessList"`
+}
+
+// ApplyTo sets the tx params read from std input to flags. Empty params do
+// not override flags.
+func (input *StdInput) ApplyTo(flag *flags.Flags) {
+	setIfNotEmpty(&flag.FlagChainID, input.ChainId)
+	setIfNotEmpty(&flag.FlagRpcUrl, input.RpcUrl)
+	setIfNotEmpty(&flag.FlagNonce, input.TxCount)
+	setIfNotEmpty(&flag.FlagFrom, input.From)
+	setIfNotEmpty(&flag.FlagTo, input.To)
+	setIfNotEmpty(&flag.FlagGasLimit, input.Gas)
+	setIfNotEmpty(&flag.FlagGasPrice, input.GasPrice)
+	setIfNotEmpty(&flag.FlagGasTip, input.GasTip)
+	setIfNotEmpty(&flag.FlagGasFeeCap, input.GasPrice)
+	setIfNotEmpty(&flag.FlagValue, input.Value)
+	setIfNotEmpty(&flag.FlagInput, input.Data)
+	setIfNotEmpty(&flag.FlagInputMethod, input.Method)
+	if len(input.AccessList) > 0 {
+		flag.FlagAccessList = string(input.AccessList)
+	}
+}
+
+func setIfNotEmpty(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
 }
 
 var flag = flags.Flags{}
 
+// exit code of the process, set to non-zero if a command fails
+var exitCode = 0
+
 func init() {
 	rootCmd.PersistentFlags().StringVar(&flag.KeystorePath, "keystore", "", "A key-store directory path")
 	rootCmd.PersistentFlags().BoolVar(&flag.UseTrezor, "trezor", false, "Use trezor wallet")
 	rootCmd.PersistentFlags().BoolVar(&flag.UseLedger, "ledger", false, "Use ledger wallet")
 	rootCmd.PersistentFlags().IntVarP(&flag.Max, "max", "n", 2, "max hd-paths to derive from")
 	rootCmd.PersistentFlags().BoolVarP(&flag.FlagVerbose, "verbose", "v", false, "output debug info")
+	rootCmd.PersistentFlags().BoolVar(&flag.FlagJSON, "json", false, "output a JSON result or error object of a command")
+	rootCmd.PersistentFlags().StringVar(&flag.AccountCachePath, "account-cache", hwwallet.DefaultAccountCachePath(), "a file caching hd-paths of found hw wallet accounts")
+	rootCmd.PersistentFlags().BoolVar(&flag.NoAccountCache, "no-account-cache", false, "do not use hw wallet account cache")
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		if flag.KeystorePath == "" && !flag.UseTrezor && !flag.UseLedger {
+		// errors are reported as JSON objects
+		cmd.SilenceUsage = flag.FlagJSON
+		cmd.SilenceErrors = flag.FlagJSON
+		hwwallet.AccountCachePath = flag.AccountCachePath
+		if flag.NoAccountCache {
+			hwwallet.AccountCachePath = ""
+		}
+		// verify-msg verifies in software without a wallet type
+		if flag.KeystorePath == "" && !flag.UseTrezor && !flag.UseLedger && flag.FlagXPub == "" && cmd != verifyMsgCmd {
 			return errors.New("Specify wallet type to connect to: --keystore, --trezor or --ledger")
 		}
 		return nil
@@ -52,31 +93,47 @@ func init() {
 
 	// list cmd flags
 	listAccountsCmd.Flags().StringVar(&flag.Hdpath, "hd", "", "hd derivation path")
+	listAccountsCmd.Flags().BoolVar(&flag.FlagDiscover, "discover", false, "discover hw wallet accounts over ledger-live, ledger-legacy and bip44 path schemes")
+	listAccountsCmd.Flags().StringVar(&flag.FlagSchemes, "schemes", "", "comma separated path schemes to discover: ledger-live, ledger-legacy, bip44. Default all")
+	listAccountsCmd.Flags().IntVar(&flag.FlagGapLimit, "gap", 20, "stop discovery after this many unused accounts in a row. Requires --rpc-url")
+	listAccountsCmd.Flags().StringVar(&flag.FlagXPub, "xpub", "", "list accounts derived offline from an extended public key (watch-only, no wallet needed)")
+	listAccountsCmd.Flags().BoolVar(&flag.FlagWatch, "watch", false, "fetch an extended public key of --xpub-path once from hw wallet and derive accounts offline")
+	listAccountsCmd.Flags().StringVar(&flag.FlagXPubPath, "xpub-path", hwwallet.DefaultXPubPath, "hd derivation path of the extended public key, accounts are its children. Required with --xpub to report the account paths")
+	listAccountsCmd.Flags().StringVar(&flag.FlagRpcUrl, "rpc-url", "", "a node json-rpc url used to check if discovered accounts are used (non-zero nonce or balance)")
+
+	// xpub flags
+	xpubCmd.Flags().StringVar(&flag.FlagXPubPath, "hd", hwwallet.DefaultXPubPath, "hd derivation path of the extended public key")
 
 	// sign tx flags
-	signCmd.Flags().StringVar(&flag.FlagNonce, "nonce", "", "")
-	signCmd.Flags().StringVar(&flag.FlagFrom, "from", "", "an account to send from")
-	signCmd.Flags().StringVar(&flag.FlagTo, "to", "", "send to or if not provided then input required with contract data")
-	signCmd.Flags().StringVar(&flag.FlagGasLimit, "gaslimit", "", "in wei")
-	signCmd.Flags().StringVar(&flag.FlagGasPrice, "gasprice", "", "for legacy tx")
-	signCmd.Flags().StringVar(&flag.FlagGasTip, "gastip", "", "for dynamic tx")
-	signCmd.Flags().StringVar(&flag.FlagGasFeeCap, "gasfeecap", "", "for dynamic tx")
-	signCmd.Flags().StringVar(&flag.FlagValue, "value", "", "in wei")
-	signCmd.Flags().BoolVar(&flag.FlagGasPriceGwei, "gasprice-gwei", false, "indicate that provided --gasprice is in gwei and not in wei")
-	signCmd.Flags().BoolVar(&flag.FlagGasTipGwei, "gastip-gwei", false, "indicate that provided --gastip is in gwei and not in wei")
-	signCmd.Flags().BoolVar(&flag.FlagGasFeeCapGwei, "gasfeecap-gwei", false, "indicate that provided --gasfeecap is in gwei and not in wei")
-	signCmd.Flags().BoolVar(&flag.FlagValueGwei, "value-gwei", false, "indicate that provided --value is in gwei and not in wei")
-	signCmd.Flags().BoolVar(&flag.FlagValueEth, "value-eth", false, "indicate that provided --value is in eth and not in wei")
-	signCmd.Flags().StringVar(&flag.FlagChainID, "chain-id", "", "1: mainnet, 5: goerli, 250: Fantom, 137: Matic/Polygon")
-	signCmd.Flags().StringVar(&flag.FlagInput, "input", "", "A hexadecimal input data for tx")
-	signCmd.Flags().StringVar(&flag.FlagInputMethod, "input-argtypes", "", "Input argument types like: address,string etc.")
-	signCmd.Flags().BoolVar(&flag.FlagSig, "sig", false, "output only signature parts(r,s,v) in hex")
-	signCmd.Flags().BoolVar(&flag.Plain, "plain", false, "print tx params and ask confirmation")
+	addSignTxFlags(signCmd)
+
+	// send tx flags
+	addSignTxFlags(sendCmd)
+	sendCmd.Flags().BoolVar(&flag.FlagWait, "wait", false, "wait for the tx to be mined and print its receipt")
+	sendCmd.Flags().IntVar(&flag.FlagWaitTimeout, "timeout", 120, "max seconds to wait for the receipt")
+
+	// sign batch flags
+	addSignTxFlags(signBatchCmd)
+	signBatchCmd.Flags().StringVar(&flag.FlagFile, "file", "", "a path to JSONL file of txs to sign, one std input json object per line. If not provided then read from std input")
 
 	// sign msg flags
 	signMsgCmd.Flags().StringVar(&flag.FlagFrom, "from", "", "an account to use to sign")
 	signMsgCmd.Flags().StringVar(&flag.FlagInput, "data", "", "input data to sign. If prefixed with 0x then interpreted as hexidecimal data, otherwise as plain text")
-	signMsgCmd.Flags().BoolVar(&flag.FlagAddEthPrefix, "with-eth-prefix", false, "Add Ethereum signature prefix: 'x19Ethereum Signed Message:' in front of input data")
+	signMsgCmd.Flags().BoolVar(&flag.FlagAddEthPrefix, "with-eth-prefix", false, "Add Ethereum signature prefix: 'x19Ethereum Signed Message:' in front of input data. Keystore only, hw wallets always add it on device")
+
+	// sign typed data flags
+	signTypedCmd.Flags().StringVar(&flag.FlagFrom, "from", "", "an account to use to sign")
+	signTypedCmd.Flags().StringVar(&flag.FlagInput, "data", "", "EIP-712 typed data json document to sign")
+	signTypedCmd.Flags().StringVar(&flag.FlagFile, "file", "", "a path to EIP-712 typed data json file to sign")
+
+	// verify message flags
+	verifyMsgCmd.Flags().StringVar(&flag.FlagFrom, "from", "", "an address that signed the message")
+	verifyMsgCmd.Flags().StringVar(&flag.FlagInput, "data", "", "signed data (with 0x prefix means hexadecimal data, otherwise plain text), Ethereum signature prefix is added")
+	verifyMsgCmd.Flags().StringVar(&flag.FlagSignature, "sig", "", "a signature of data to verify. With --trezor verified on device screen, otherwise in software without a wallet")
+
+	// verify address flags
+	verifyAddressCmd.Flags().StringVar(&flag.FlagFrom, "from", "", "an account address to show on device")
+	verifyAddressCmd.Flags().StringVar(&flag.Hdpath, "hd", "", "hd derivation path of an account to show on device")
 
 	// recover address flags
 	recoverCmd.Flags().StringVar(&flag.FlagInput, "data", "", "input data (with 0x prefix means hexadecimal data, otherwise plain text) that was used to generate a signature")
@@ -87,20 +144,130 @@ func init() {
 	hwEncryptCmd.Flags().StringVar(&flag.FlagFrom, "from", "", "an account to use to encrypt")
 	hwEncryptCmd.Flags().StringVar(&flag.FlagKey, "key", "", "a key used to encrypt (with 0x prefix means hexadecimal data, otherwise plain text)")
 	hwEncryptCmd.Flags().StringVar(&flag.FlagInput, "data", "", "input data (with 0x prefix means hexadecimal data, otherwise plain text) to encrypt")
+	hwEncryptCmd.Flags().StringVar(&flag.Hdpath, "hd", "", "hd derivation path of an account to use instead of --from")
+	hwEncryptCmd.Flags().StringVar(&flag.FlagIv, "iv", "", "a 16 bytes AES-CBC IV in hex, with or without 0x. Default empty lets the device derive it")
+	hwEncryptCmd.Flags().BoolVar(&flag.FlagAskOnEncrypt, "ask-on-encrypt", true, "ask a confirmation on device when encrypting, must be the same on encrypt and decrypt")
+	hwEncryptCmd.Flags().BoolVar(&flag.FlagAskOnDecrypt, "ask-on-decrypt", true, "ask a confirmation on device when decrypting, must be the same on encrypt and decrypt")
+	hwEncryptCmd.Flags().BoolVar(&flag.FlagNoPadding, "no-padding", false, "do not pkcs7 pad data, data must be a multiple of 16 bytes (like trezorctl)")
 
 	// decrypt
 	hwDecryptCmd.Flags().StringVar(&flag.FlagFrom, "from", "", "an account to use to decrypt")
 	hwDecryptCmd.Flags().StringVar(&flag.FlagKey, "key", "", "a key used to decrypt (with 0x prefix means hexadecimal data, otherwise plain text)")
 	hwDecryptCmd.Flags().StringVar(&flag.FlagInput, "data", "", "input data (with 0x prefix means hexadecimal data, otherwise plain text) to decrypt")
+	hwDecryptCmd.Flags().StringVar(&flag.Hdpath, "hd", "", "hd derivation path of an account to use instead of --from")
+	hwDecryptCmd.Flags().StringVar(&flag.FlagIv, "iv", "", "a 16 bytes AES-CBC IV in hex, with or without 0x. Default empty lets the device derive it")
+	hwDecryptCmd.Flags().BoolVar(&flag.FlagAskOnEncrypt, "ask-on-encrypt", true, "ask a confirmation on device when encrypting, must be the same on encrypt and decrypt")
+	hwDecryptCmd.Flags().BoolVar(&flag.FlagAskOnDecrypt, "ask-on-decrypt", true, "ask a confirmation on device when decrypting, must be the same on encrypt and decrypt")
+	hwDecryptCmd.Flags().BoolVar(&flag.FlagNoPadding, "no-padding", false, "do not strip pkcs7 padding from decrypted data (like trezorctl)")
+
+	// encrypt file
+	encryptFileCmd.Flags().StringVar(&flag.FlagFrom, "from", "", "an account to use to encrypt the data key")
+	encryptFileCmd.Flags().StringVar(&flag.Hdpath, "hd", "", "hd derivation path of an account to use instead of --from")
+	encryptFileCmd.Flags().StringVar(&flag.FlagKeyName, "key", "jethwallet file key", "a key name shown on device, needed to decrypt the data key")
+	encryptFileCmd.Flags().StringVar(&flag.FlagFile, "file", "", "a file to encrypt")
+	encryptFileCmd.Flags().StringVar(&flag.FlagOut, "out", "", "an encrypted file to write. Default --file with "+envelopeExt+" extension")
+	encryptFileCmd.Flags().BoolVar(&flag.FlagAskOnEncrypt, "ask-on-encrypt", true, "ask a confirmation on device when encrypting the data key")
+	encryptFileCmd.Flags().BoolVar(&flag.FlagAskOnDecrypt, "ask-on-decrypt", true, "ask a confirmation on device when decrypting the data key")
+
+	// decrypt file
+	decryptFileCmd.Flags().StringVar(&flag.FlagFile, "file", "", "an encrypted file to decrypt")
+	decryptFileCmd.Flags().StringVar(&flag.FlagOut, "out", "", "a decrypted file to write. Default --file without "+envelopeExt+" extension")
+
+	// trezor management flags
+	trezorCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		flag.UseTrezor = true
+		return rootCmd.PersistentPreRunE(cmd, args)
+	}
+	trezorSettingsCmd.Flags().StringVar(&flag.FlagLabel, "label", "", "a new device label")
+	trezorSettingsCmd.Flags().StringVar(&flag.FlagPassphrase, "passphrase", "", "passphrase protection: on or off")
+	trezorChangePinCmd.Flags().BoolVar(&flag.FlagRemove, "remove", false, "remove the PIN")
+	trezorChangeWipeCodeCmd.Flags().BoolVar(&flag.FlagRemove, "remove", false, "remove the wipe code")
+	trezorWipeCmd.Flags().BoolVar(&flag.FlagYes, "yes", false, "confirm erasing the seed from device")
+	trezorPingCmd.Flags().StringVar(&flag.FlagMessage, "message", "ping", "a message device returns back")
+	trezorPingCmd.Flags().BoolVar(&flag.FlagButton, "button", false, "ask a button confirmation on device")
+	trezorResetCmd.Flags().IntVar(&flag.FlagStrength, "strength", 256, "seed strength in bits: 128, 192 or 256 (12, 18 or 24 words)")
+	trezorResetCmd.Flags().StringVar(&flag.FlagLabel, "label", "", "a device label")
+	trezorResetCmd.Flags().StringVar(&flag.FlagPassphrase, "passphrase", "", "passphrase protection: on or off")
+	trezorResetCmd.Flags().BoolVar(&flag.FlagPin, "pin", true, "set a PIN")
+	trezorResetCmd.Flags().BoolVar(&flag.FlagSkipBackup, "skip-backup", false, "postpone showing the seed words to: trezor backup")
+	trezorRecoverCmd.Flags().IntVar(&flag.FlagWordCount, "words", 24, "number of seed words: 12, 18 or 24")
+	trezorRecoverCmd.Flags().BoolVar(&flag.FlagMatrix, "matrix", false, "enter words as letter positions of device matrix instead of scrambled words")
+	trezorRecoverCmd.Flags().BoolVar(&flag.FlagDryRun, "dry-run", false, "check the words match the device seed without changing it")
+	trezorRecoverCmd.Flags().StringVar(&flag.FlagLabel, "label", "", "a device label")
+	trezorRecoverCmd.Flags().StringVar(&flag.FlagPassphrase, "passphrase", "", "passphrase protection: on or off")
+	trezorRecoverCmd.Flags().BoolVar(&flag.FlagPin, "pin", true, "set a PIN")
+	trezorSignIdentityCmd.Flags().StringVar(&flag.FlagIdentity, "identity", "", "an identity uri to sign in as, like ssh://user@host:22 or https://user@example.com/login")
+	trezorSignIdentityCmd.Flags().UintVar(&flag.FlagIdentityIndex, "index", 0, "an identity index to use another key of the same identity")
+	trezorSignIdentityCmd.Flags().StringVar(&flag.FlagChallenge, "challenge", "", "a hidden challenge to sign (with 0x prefix means hexadecimal data, otherwise plain text)")
+	trezorSignIdentityCmd.Flags().StringVar(&flag.FlagVisual, "visual", "", "a challenge shown on device, like date and time")
+	trezorSignIdentityCmd.Flags().StringVar(&flag.FlagCurve, "curve", "secp256k1", "a curve of identity key: secp256k1, nist256p1 or ed25519")
+	trezorECDHCmd.Flags().StringVar(&flag.FlagIdentity, "identity", "", "an identity uri of the key, like gpg://user@example.com")
+	trezorECDHCmd.Flags().UintVar(&flag.FlagIdentityIndex, "index", 0, "an identity index to use another key of the same identity")
+	trezorECDHCmd.Flags().StringVar(&flag.FlagPeerKey, "peer", "", "a peer public key in hex")
+	trezorECDHCmd.Flags().StringVar(&flag.FlagCurve, "curve", "secp256k1", "a curve of identity key: secp256k1, nist256p1 or curve25519")
+	trezorCmd.AddCommand(trezorFeaturesCmd)
+	trezorCmd.AddCommand(trezorSettingsCmd)
+	trezorCmd.AddCommand(trezorChangePinCmd)
+	trezorCmd.AddCommand(trezorChangeWipeCodeCmd)
+	trezorCmd.AddCommand(trezorWipeCmd)
+	trezorCmd.AddCommand(trezorLockCmd)
+	trezorCmd.AddCommand(trezorEndSessionCmd)
+	trezorCmd.AddCommand(trezorPingCmd)
+	trezorCmd.AddCommand(trezorResetCmd)
+	trezorCmd.AddCommand(trezorBackupCmd)
+	trezorCmd.AddCommand(trezorRecoverCmd)
+	trezorCmd.AddCommand(trezorSignIdentityCmd)
+	trezorCmd.AddCommand(trezorECDHCmd)
 
 	rootCmd.AddCommand(listAccountsCmd)
 	rootCmd.AddCommand(newAccountCmd)
 	rootCmd.AddCommand(importKeyCmd)
+	rootCmd.AddCommand(xpubCmd)
 	rootCmd.AddCommand(signCmd)
+	rootCmd.AddCommand(sendCmd)
+	rootCmd.AddCommand(signBatchCmd)
 	rootCmd.AddCommand(signMsgCmd)
+	rootCmd.AddCommand(signTypedCmd)
 	rootCmd.AddCommand(recoverCmd)
+	rootCmd.AddCommand(verifyAddressCmd)
+	rootCmd.AddCommand(verifyMsgCmd)
+	rootCmd.AddCommand(trezorCmd)
 	rootCmd.AddCommand(hwEncryptCmd)
 	rootCmd.AddCommand(hwDecryptCmd)
+	rootCmd.AddCommand(encryptFileCmd)
+	rootCmd.AddCommand(decryptFileCmd)
+}
+
+func addSignTxFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&flag.FlagNonce, "nonce", "", "")
+	cmd.Flags().StringVar(&flag.FlagFrom, "from", "", "an account to send from")
+	cmd.Flags().StringVar(&flag.FlagTo, "to", "", "send to or if not provided then input required with contract data")
+	cmd.Flags().StringVar(&flag.FlagGasLimit, "gaslimit", "", "in wei")
+	cmd.Flags().StringVar(&flag.FlagGasPrice, "gasprice", "", "for legacy tx")
+	cmd.Flags().StringVar(&flag.FlagGasTip, "gastip", "", "for dynamic tx")
+	cmd.Flags().StringVar(&flag.FlagGasFeeCap, "gasfeecap", "", "for dynamic tx")
+	cmd.Flags().StringVar(&flag.FlagValue, "value", "", "in wei")
+	cmd.Flags().BoolVar(&flag.FlagGasPriceGwei, "gasprice-gwei", false, "indicate that provided --gasprice is in gwei and not in wei")
+	cmd.Flags().BoolVar(&flag.FlagGasTipGwei, "gastip-gwei", false, "indicate that provided --gastip is in gwei and not in wei")
+	cmd.Flags().BoolVar(&flag.FlagGasFeeCapGwei, "gasfeecap-gwei", false, "indicate that provided --gasfeecap is in gwei and not in wei")
+	cmd.Flags().BoolVar(&flag.FlagValueGwei, "value-gwei", false, "indicate that provided --value is in gwei and not in wei")
+	cmd.Flags().BoolVar(&flag.FlagValueEth, "value-eth", false, "indicate that provided --value is in eth and not in wei")
+	cmd.Flags().StringVar(&flag.FlagChainID, "chain-id", "", "1: mainnet, 5: goerli, 250: Fantom, 137: Matic/Polygon")
+	cmd.Flags().StringVar(&flag.FlagInput, "input", "", "A hexadecimal input data for tx")
+	cmd.Flags().StringVar(&flag.FlagInputMethod, "input-argtypes", "", "Input argument types like: address,string etc.")
+	cmd.Flags().StringVar(&flag.FlagMethod, "method", "", "encode input for a method like: transfer(address,uint256) or a method name from --abi")
+	cmd.Flags().StringVar(&flag.FlagArgs, "args", "", "comma separated --method args. Arrays as [a,b], tuples as (a,b), strings with commas in quotes")
+	cmd.Flags().StringVar(&flag.FlagAbi, "abi", "", "a path to contract abi json file to look up --method from")
+	cmd.Flags().StringVar(&flag.FlagAbiDir, "abi-dir", "", "a directory of contract abi json files used to decode input in --plain preview")
+	cmd.Flags().StringVar(&flag.FlagSigFile, "4byte", "", "an offline 4byte signature table (lines like: 0xa9059cbb transfer(address,uint256)) used to decode input in --plain preview")
+	cmd.Flags().StringVar(&flag.FlagTxType, "tx-type", "", "0: legacy, 1: access list (EIP-2930), 2: dynamic fee (EIP-1559). By default chosen from the provided fee params")
+	cmd.Flags().StringVar(&flag.FlagAccessList, "access-list", "", `an EIP-2930 access list json like [{"address":"0x..","storageKeys":["0x.."]}]. With --gasprice signs an access list (type 1) tx`)
+	cmd.Flags().StringVar(&flag.FlagAccessListFile, "access-list-file", "", "a path to an EIP-2930 access list json file")
+	cmd.Flags().BoolVar(&flag.FlagCreateAccessList, "create-access-list", false, "generate the access list with eth_createAccessList of --rpc-url node")
+	cmd.Flags().StringVar(&flag.FlagDescriptors, "ledger-descriptors", "", "a path to a json file of Ledger signed ERC-20 token and plugin descriptors, provided to the Ledger before signing to display token amounts instead of raw data")
+	cmd.Flags().BoolVar(&flag.FlagSig, "sig", false, "output only signature parts(r,s,v) in hex")
+	cmd.Flags().BoolVar(&flag.Plain, "plain", false, "print tx params and ask confirmation")
+	cmd.Flags().StringVar(&flag.FlagRpcUrl, "rpc-url", "", "a node json-rpc url used to fill in missing --chain-id, --nonce, --gaslimit and fee params")
 }
 
 var rootCmd = &cobra.Command{
@@ -113,18 +280,23 @@ var listAccountsCmd = &cobra.Command{
 	Aliases: []string{"ls"},
 	Short:   "List accounts",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		term := ui.NewTerminal(flag.FlagVerbose)
-		var err error
-		if flag.KeystorePath != "" {
-			err = keystore.ListAccounts(term, flag.KeystorePath, flag.FlagVerbose)
-		} else {
+		return runCommand(func(term ui.Screen) error {
+			term.ListResults()
+			if flag.FlagXPub != "" || flag.FlagWatch {
+				if flag.FlagXPub != "" && !cmd.Flags().Changed("xpub-path") {
+					flag.FlagXPubPath = ""
+				}
+				return ListWatchAccounts(term, &flag)
+			}
+			if flag.KeystorePath != "" {
+				return keystore.ListAccounts(term, flag.KeystorePath, flag.FlagVerbose)
+			}
 			walletType := hwcommon.GetWalletTypeFromFlags(&flag)
-			err = hwwallet.ListAccounts(term, walletType, flag.Hdpath, flag.Max, flag.FlagVerbose)
-		}
-		if err != nil {
-			term.Error(err)
-		}
-		return nil
+			if flag.FlagDiscover {
+				return DiscoverAccounts(term, walletType, &flag)
+			}
+			return hwwallet.ListAccounts(term, walletType, flag.Hdpath, flag.Max, flag.FlagVerbose)
+		})
 	},
 }
 
@@ -132,12 +304,9 @@ var newAccountCmd = &cobra.Command{
 	Use:   "new",
 	Short: "Create a new account in keystore",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		term := ui.NewTerminal(flag.FlagVerbose)
-		err := keystore.NewAccount(term, flag.KeystorePath)
-		if err != nil {
-			term.Error(err)
-		}
-		return nil
+		return runCommand(func(term ui.Screen) error {
+			return keystore.NewAccount(term, flag.KeystorePath)
+		})
 	},
 }
 
@@ -145,12 +314,19 @@ var importKeyCmd = &cobra.Command{
 	Use:   "import-key",
 	Short: "import hexadecimal private key into keystore",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		term := ui.NewTerminal(flag.FlagVerbose)
-		err := keystore.ImportKey(term, flag.KeystorePath)
-		if err != nil {
-			term.Error(err)
-		}
-		return nil
+		return runCommand(func(term ui.Screen) error {
+			return keystore.ImportKey(term, flag.KeystorePath)
+		})
+	},
+}
+
+var xpubCmd = &cobra.Command{
+	Use:   "xpub",
+	Short: "Export an extended public key from hw wallet",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return ExportXPub(term, &flag)
+		})
 	},
 }
 
@@ -159,12 +335,31 @@ var signCmd = &cobra.Command{
 	Aliases: []string{"tx"},
 	Short:   "Sign a transaction",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		term := ui.NewTerminal(flag.FlagVerbose)
-		err := SignTx(term, &flag)
-		if err != nil {
-			term.Error(err)
-		}
-		return nil
+		return runCommand(func(term ui.Screen) error {
+			return SignTx(term, &flag)
+		})
+	},
+}
+
+var sendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Sign a transaction and broadcast it to the node",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return SendTx(cmd.Context(), term, &flag)
+		})
+	},
+}
+
+var signBatchCmd = &cobra.Command{
+	Use:     "sign-batch",
+	Aliases: []string{"batch"},
+	Short:   "Sign many transactions from JSONL input",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			term.ListResults()
+			return SignBatch(term, &flag)
+		})
 	},
 }
 
@@ -173,12 +368,175 @@ var signMsgCmd = &cobra.Command{
 	Aliases: []string{"msg"},
 	Short:   "Sign a message",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		term := ui.NewTerminal(flag.FlagVerbose)
-		err := SignMsg(term, &flag)
-		if err != nil {
-			term.Error(err)
-		}
-		return nil
+		return runCommand(func(term ui.Screen) error {
+			return SignMsg(term, &flag)
+		})
+	},
+}
+
+var signTypedCmd = &cobra.Command{
+	Use:     "sign-typed",
+	Aliases: []string{"typed"},
+	Short:   "Sign EIP-712 typed data",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return SignTypedMsg(term, &flag)
+		})
+	},
+}
+
+var trezorCmd = &cobra.Command{
+	Use:   "trezor",
+	Short: "Manage a Trezor device",
+}
+
+var trezorFeaturesCmd = &cobra.Command{
+	Use:   "features",
+	Short: "Print all device features as JSON",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorFeatures(term, &flag)
+		})
+	},
+}
+
+var trezorSettingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Change the device label and passphrase protection",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorSettings(term, &flag)
+		})
+	},
+}
+
+var trezorChangePinCmd = &cobra.Command{
+	Use:   "change-pin",
+	Short: "Set, change or remove the PIN",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorChangePin(term, &flag)
+		})
+	},
+}
+
+var trezorChangeWipeCodeCmd = &cobra.Command{
+	Use:   "change-wipe-code",
+	Short: "Set, change or remove the wipe code",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorChangeWipeCode(term, &flag)
+		})
+	},
+}
+
+var trezorWipeCmd = &cobra.Command{
+	Use:   "wipe",
+	Short: "Erase the seed and settings from device",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorWipe(term, &flag)
+		})
+	},
+}
+
+var trezorLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the device",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorLock(term, &flag)
+		})
+	},
+}
+
+var trezorEndSessionCmd = &cobra.Command{
+	Use:   "end-session",
+	Short: "End the session and forget the cached passphrase",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorEndSession(term, &flag)
+		})
+	},
+}
+
+var trezorPingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Ping the device",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorPing(term, &flag)
+		})
+	},
+}
+
+var trezorResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Initialize a new seed on device",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorReset(term, &flag)
+		})
+	},
+}
+
+var trezorBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Show the seed words of a device initialized without backup",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorBackup(term, &flag)
+		})
+	},
+}
+
+var trezorRecoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Restore a seed on device from its words",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorRecover(term, &flag)
+		})
+	},
+}
+
+var trezorSignIdentityCmd = &cobra.Command{
+	Use:   "sign-identity",
+	Short: "Sign a login challenge with an identity key",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorSignIdentity(term, &flag)
+		})
+	},
+}
+
+var trezorECDHCmd = &cobra.Command{
+	Use:   "ecdh",
+	Short: "Derive a shared secret with a peer public key",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return TrezorECDH(term, &flag)
+		})
+	},
+}
+
+var verifyMsgCmd = &cobra.Command{
+	Use:   "verify-msg",
+	Short: "Verify a message signature, on device screen with --trezor",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return VerifyMsg(term, &flag)
+		})
+	},
+}
+
+var verifyAddressCmd = &cobra.Command{
+	Use:   "verify-address",
+	Short: "Show an account address on hw wallet screen to verify it",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return VerifyAddress(term, &flag)
+		})
 	},
 }
 
@@ -186,12 +544,9 @@ var recoverCmd = &cobra.Command{
 	Use:   "recover",
 	Short: "Recover an address from signature",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		term := ui.NewTerminal(flag.FlagVerbose)
-		err := RecoverAddress(term, &flag)
-		if err != nil {
-			term.Error(err)
-		}
-		return nil
+		return runCommand(func(term ui.Screen) error {
+			return RecoverAddress(term, &flag)
+		})
 	},
 }
 
@@ -200,12 +555,9 @@ var hwEncryptCmd = &cobra.Command{
 	Aliases: []string{"hwe"},
 	Short:   "Encrypt on Trezor wallet",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		term := ui.NewTerminal(flag.FlagVerbose)
-		err := HwEncrypt(term, &flag)
-		if err != nil {
-			term.Error(err)
-		}
-		return nil
+		return runCommand(func(term ui.Screen) error {
+			return HwEncrypt(term, &flag)
+		})
 	},
 }
 
@@ -214,13 +566,51 @@ var hwDecryptCmd = &cobra.Command{
 	Aliases: []string{"hwd"},
 	Short:   "Decrypt on Trezor wallet",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		term := ui.NewTerminal(flag.FlagVerbose)
-		err := HwDecrypt(term, &flag)
+		return runCommand(func(term ui.Screen) error {
+			return HwDecrypt(term, &flag)
+		})
+	},
+}
+
+var encryptFileCmd = &cobra.Command{
+	Use:   "encrypt-file",
+	Short: "Encrypt a file with a data key wrapped on Trezor wallet",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return EncryptFile(term, &flag)
+		})
+	},
+}
+
+var decryptFileCmd = &cobra.Command{
+	Use:   "decrypt-file",
+	Short: "Decrypt a file encrypted with encrypt-file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCommand(func(term ui.Screen) error {
+			return DecryptFile(term, &flag)
+		})
+	},
+}
+
+// runCommand runs a command on a terminal selected by --json flag and
+// reports its error
+func runCommand(fn func(term ui.Screen) error) error {
+	if flag.FlagJSON {
+		term := ui.NewJSONTerminal(flag.FlagVerbose)
+		err := fn(term)
+		term.Finish(err)
 		if err != nil {
-			term.Error(err)
+			exitCode = 1
 		}
 		return nil
-	},
+	}
+	term := ui.NewTerminal(flag.FlagVerbose)
+	err := fn(term)
+	if err != nil {
+		term.Error(err)
+		exitCode = 1
+	}
+	return nil
 }
 
 func main() {
@@ -236,29 +626,26 @@ func main() {
 			err := json.Unmarshal([]byte(stdInStr), &input)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error while parsing stdin json: %s\n", err)
-				return
+				cancel()
+				os.Exit(1)
 			} else {
-				flag.FlagChainID = input.ChainId
-				flag.FlagRpcUrl = input.RpcUrl
-				flag.FlagNonce = input.TxCount
-				flag.FlagFrom = input.From
-				flag.FlagTo = input.To
-				flag.FlagGasLimit = input.Gas
-				flag.FlagGasPrice = input.GasPrice
-				flag.FlagGasTip = input.GasTip
-				flag.FlagGasFeeCap = input.GasPrice
-				flag.FlagValue = input.Value
-				flag.FlagInput = input.Data
-				flag.FlagInputMethod = input.Method
+				input.ApplyTo(&flag)
 			}
 		}
 	}
 
 	// run command
 	if err := rootCmd.ExecuteContext(ctx); err != nil {
-		fmt.Println(err)
+		if flag.FlagJSON {
+			ui.NewJSONTerminal(flag.FlagVerbose).Finish(err)
+		} else {
+			fmt.Println(err)
+		}
+		cancel()
 		os.Exit(1)
 	}
+	cancel()
+	os.Exit(exitCode)
 }
 
 func RootContext() (context.Context, context.CancelFunc) {
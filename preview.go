@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/holiman/uint256"
+	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/rpc"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/jaanek/jethwallet/wallet"
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+var (
+	erc20SymbolSelector   = []byte{0x95, 0xd8, 0x9b, 0x41} // symbol()
+	erc20DecimalsSelector = []byte{0x31, 0x3c, 0xe5, 0x67} // decimals()
+)
+
+// decodeInput decodes tx input with the builtin signatures, --4byte table and
+// --abi-dir contract abis. Returns nil if input can not be decoded.
+func decodeInput(term ui.Screen, flag *flags.Flags, input []byte) *wallet.DecodedCall {
+	registry, err := wallet.NewSignatureRegistry()
+	if err != nil {
+		term.Errorf("Error while loading signatures: %v\n", err)
+		return nil
+	}
+	if flag.FlagSigFile != "" {
+		if err := registry.LoadSignatureFile(flag.FlagSigFile); err != nil {
+			term.Errorf("Error while loading --4byte: %v\n", err)
+		}
+	}
+	if flag.FlagAbiDir != "" {
+		if err := registry.LoadABIDir(flag.FlagAbiDir); err != nil {
+			term.Errorf("Error while loading --abi-dir: %v\n", err)
+		}
+	}
+	call, err := registry.Decode(input)
+	if err != nil {
+		term.Logf("Could not decode input: %v\n", err)
+		return nil
+	}
+	return call
+}
+
+// printCall prints decoded method with named args. Amounts of known ERC-20
+// calls are also rendered in token units.
+func printCall(term ui.Screen, client *rpc.Client, chainID *uint256.Int, to *common.Address, call *wallet.DecodedCall) {
+	term.Print(fmt.Sprintf("method: %s", call.Method.Sig))
+	var token *wallet.Token
+	if to != nil && wallet.IsERC20AmountCall(call) {
+		token = lookupToken(term, client, chainID, *to)
+	}
+	for i, arg := range call.Args {
+		input := call.Method.Inputs[i]
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		value := formatArg(input.Type, arg)
+		if token != nil && i == len(call.Args)-1 {
+			value = fmt.Sprintf("%s (%s %s)", value, wallet.FormatUnits(arg.(*big.Int), token.Decimals), token.Symbol)
+		}
+		term.Print(fmt.Sprintf("  %s (%s): %s", name, input.Type, value))
+	}
+}
+
+func formatArg(t abi.Type, arg interface{}) string {
+	switch t.T {
+	case abi.BytesTy, abi.FixedBytesTy:
+		return fmt.Sprintf("0x%x", arg)
+	default:
+		return fmt.Sprintf("%v", arg)
+	}
+}
+
+// lookupToken finds token info from the well-known tokens or from the node.
+// Returns nil if the token is unknown.
+func lookupToken(term ui.Screen, client *rpc.Client, chainID *uint256.Int, addr common.Address) *wallet.Token {
+	if token, ok := wallet.LookupToken(chainID.Uint64(), addr); ok {
+		return &token
+	}
+	if client == nil {
+		return nil
+	}
+	out, err := client.CallContract(rpc.CallMsg{To: &addr, Data: erc20DecimalsSelector})
+	if err != nil || len(out) != 32 {
+		term.Logf("Could not get token decimals: %v\n", err)
+		return nil
+	}
+	decimals := new(big.Int).SetBytes(out)
+	if !decimals.IsUint64() || decimals.Uint64() > 77 {
+		return nil
+	}
+	token := wallet.Token{Decimals: uint8(decimals.Uint64())}
+	out, err = client.CallContract(rpc.CallMsg{To: &addr, Data: erc20SymbolSelector})
+	if err != nil {
+		term.Logf("Could not get token symbol: %v\n", err)
+		return &token
+	}
+	token.Symbol = decodeSymbol(out)
+	return &token
+}
+
+// decodeSymbol decodes symbol() output which is a string or bytes32 in old
+// tokens
+func decodeSymbol(out []byte) string {
+	stringType, _ := abi.NewType("string", "", nil)
+	values, err := abi.Arguments{{Type: stringType}}.Unpack(out)
+	if err == nil && len(values) == 1 {
+		return values[0].(string)
+	}
+	if len(out) == 32 {
+		end := 0
+		for end < len(out) && out[end] != 0 {
+			end++
+		}
+		return string(out[:end])
+	}
+	return ""
+}
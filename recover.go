@@ -10,6 +10,10 @@ import (
 	"github.com/ledgerwatch/erigon/common/hexutil"
 )
 
+type AddressOutput struct {
+	Address string `json:"address"`
+}
+
 func RecoverAddress(term ui.Screen, flag *flags.Flags) error {
 	if flag.FlagInput == "" {
 		return errors.New("Missing --data")
@@ -32,6 +36,6 @@ func RecoverAddress(term ui.Screen, flag *flags.Flags) error {
 	if err != nil {
 		return err
 	}
-	term.Output(hexutil.Encode(addr[:]))
+	term.Result(hexutil.Encode(addr[:]), &AddressOutput{Address: addr.Hex()})
 	return nil
 }
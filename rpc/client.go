@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Client is a minimal JSON-RPC 2.0 client talking to an ethereum node over http.
+type Client struct {
+	url    string
+	http   *http.Client
+	nextID uint64
+}
+
+type request struct {
+	JsonRpc string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type response struct {
+	JsonRpc string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+}
+
+// Error is an error object returned by the node.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error implements the standard error interface.
+func (err *Error) Error() string {
+	if err.Data != nil {
+		return fmt.Sprintf("rpc error %d: %s (%v)", err.Code, err.Message, err.Data)
+	}
+	return fmt.Sprintf("rpc error %d: %s", err.Code, err.Message)
+}
+
+// NewClient creates a client for the given node url.
+func NewClient(url string) *Client {
+	return NewClientWithHTTP(url, &http.Client{Timeout: 30 * time.Second})
+}
+
+// NewClientWithHTTP creates a client for the given node url using a custom
+// http client.
+func NewClientWithHTTP(url string, client *http.Client) *Client {
+	return &Client{
+		url:  url,
+		http: client,
+	}
+}
+
+// URL returns the node url the client talks to.
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Call performs a JSON-RPC call with the given method and params and decodes
+// the result into the value pointed to by result.
+func (c *Client) Call(result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	c.nextID++
+	body, err := json.Marshal(&request{
+		JsonRpc: "2.0",
+		ID:      c.nextID,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Post(c.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: http status %s: %s", method, resp.Status, string(respBody))
+	}
+	var msg response
+	if err := json.Unmarshal(respBody, &msg); err != nil {
+		return fmt.Errorf("%s: invalid response: %w", method, err)
+	}
+	if msg.Error != nil {
+		return fmt.Errorf("%s: %w", method, msg.Error)
+	}
+	if len(msg.Result) == 0 || string(msg.Result) == "null" {
+		return fmt.Errorf("%s: %w", method, ErrNoResult)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(msg.Result, result)
+}
+
+// ErrNoResult is returned when the node replied with an empty result.
+var ErrNoResult = errors.New("no result in JSON-RPC response")
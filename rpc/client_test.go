@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// stubReply is the raw json result or error object a stub node replies with
+type stubReply struct {
+	result string
+	err    string
+}
+
+// newStubNode starts a JSON-RPC node replying to methods from replies. The
+// params of each call are recorded by method.
+func newStubNode(t *testing.T, replies map[string]stubReply) (*httptest.Server, map[string]json.RawMessage) {
+	t.Helper()
+	params := make(map[string]json.RawMessage)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var req struct {
+			ID     uint64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("invalid request %s: %v", body, err)
+			return
+		}
+		params[req.Method] = req.Params
+		reply, ok := replies[req.Method]
+		if !ok {
+			reply = stubReply{err: `{"code":-32601,"message":"the method does not exist"}`}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if reply.err != "" {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":` + jsonID(req.ID) + `,"error":` + reply.err + `}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + jsonID(req.ID) + `,"result":` + reply.result + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, params
+}
+
+func jsonID(id uint64) string {
+	b, _ := json.Marshal(id)
+	return string(b)
+}
+
+func TestCallRPCError(t *testing.T) {
+	srv, _ := newStubNode(t, map[string]stubReply{
+		"eth_chainId": {err: `{"code":-32000,"message":"boom","data":"0x01"}`},
+	})
+	_, err := NewClient(srv.URL).ChainID()
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("expected rpc error, got %v", err)
+	}
+	if rpcErr.Code != -32000 || rpcErr.Message != "boom" {
+		t.Fatalf("unexpected rpc error: %+v", rpcErr)
+	}
+	if !strings.HasPrefix(err.Error(), "eth_chainId: ") {
+		t.Fatalf("error not prefixed with the method: %v", err)
+	}
+}
+
+func TestCallHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	_, err := NewClient(srv.URL).ChainID()
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("expected http status error, got %v", err)
+	}
+}
+
+func TestCallInvalidResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not json"))
+	}))
+	defer srv.Close()
+	_, err := NewClient(srv.URL).ChainID()
+	if err == nil || !strings.Contains(err.Error(), "invalid response") {
+		t.Fatalf("expected invalid response error, got %v", err)
+	}
+}
+
+func TestCallNullResult(t *testing.T) {
+	srv, _ := newStubNode(t, map[string]stubReply{
+		"eth_chainId": {result: "null"},
+	})
+	_, err := NewClient(srv.URL).ChainID()
+	if !errors.Is(err, ErrNoResult) {
+		t.Fatalf("expected ErrNoResult, got %v", err)
+	}
+}
+
+func TestCallUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+	if _, err := NewClient(url).ChainID(); err == nil {
+		t.Fatal("expected an error from a closed node")
+	}
+}
@@ -0,0 +1,211 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// CallMsg contains the parameters of a message call, used for gas estimation.
+type CallMsg struct {
+	From       common.Address   `json:"from"`
+	To         *common.Address  `json:"to,omitempty"`
+	Value      *hexutil.Big     `json:"value,omitempty"`
+	Data       hexutil.Bytes    `json:"data,omitempty"`
+	AccessList types.AccessList `json:"accessList,omitempty"`
+}
+
+// NewCallMsg creates a call message from the given tx params.
+func NewCallMsg(from common.Address, to *common.Address, value *uint256.Int, data []byte) CallMsg {
+	msg := CallMsg{
+		From: from,
+		To:   to,
+		Data: data,
+	}
+	if value != nil {
+		msg.Value = (*hexutil.Big)(value.ToBig())
+	}
+	return msg
+}
+
+// FeeHistory is the result of eth_feeHistory.
+type FeeHistory struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// ChainID retrieves the chain id of the node with eth_chainId.
+func (c *Client) ChainID() (*uint256.Int, error) {
+	var result hexutil.Big
+	if err := c.Call(&result, "eth_chainId"); err != nil {
+		return nil, err
+	}
+	return toUint256((*big.Int)(&result))
+}
+
+// PendingNonceAt returns the account nonce including the pending txs with
+// eth_getTransactionCount.
+func (c *Client) PendingNonceAt(addr common.Address) (uint64, error) {
+	var result hexutil.Uint64
+	if err := c.Call(&result, "eth_getTransactionCount", addr, "pending"); err != nil {
+		return 0, err
+	}
+	return uint64(result), nil
+}
+
+// NonceAt returns the account nonce of the latest block with
+// eth_getTransactionCount.
+func (c *Client) NonceAt(addr common.Address) (uint64, error) {
+	var result hexutil.Uint64
+	if err := c.Call(&result, "eth_getTransactionCount", addr, "latest"); err != nil {
+		return 0, err
+	}
+	return uint64(result), nil
+}
+
+// BalanceAt returns the account balance of the latest block with
+// eth_getBalance.
+func (c *Client) BalanceAt(addr common.Address) (*uint256.Int, error) {
+	var result hexutil.Big
+	if err := c.Call(&result, "eth_getBalance", addr, "latest"); err != nil {
+		return nil, err
+	}
+	return toUint256((*big.Int)(&result))
+}
+
+// EstimateGas estimates the gas needed to execute the given call with
+// eth_estimateGas.
+func (c *Client) EstimateGas(msg CallMsg) (uint64, error) {
+	var result hexutil.Uint64
+	if err := c.Call(&result, "eth_estimateGas", msg); err != nil {
+		return 0, err
+	}
+	return uint64(result), nil
+}
+
+// CallContract executes a message call on the latest block with eth_call
+// and returns its output.
+func (c *Client) CallContract(msg CallMsg) ([]byte, error) {
+	var result hexutil.Bytes
+	if err := c.Call(&result, "eth_call", msg, "latest"); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AccessListResult is the result of eth_createAccessList.
+type AccessListResult struct {
+	AccessList types.AccessList `json:"accessList"`
+	GasUsed    hexutil.Uint64   `json:"gasUsed"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// CreateAccessList generates the access list of the given call on the pending
+// block with eth_createAccessList. Fails if the call reverts.
+func (c *Client) CreateAccessList(msg CallMsg) (*AccessListResult, error) {
+	var result AccessListResult
+	if err := c.Call(&result, "eth_createAccessList", msg, "pending"); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("eth_createAccessList: %s", result.Error)
+	}
+	return &result, nil
+}
+
+// GasPrice retrieves the legacy gas price suggestion with eth_gasPrice.
+func (c *Client) GasPrice() (*uint256.Int, error) {
+	var result hexutil.Big
+	if err := c.Call(&result, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return toUint256((*big.Int)(&result))
+}
+
+// FeeHistory retrieves the base fees and the priority fee percentiles of
+// the last blockCount blocks with eth_feeHistory.
+func (c *Client) FeeHistory(blockCount uint64, lastBlock string, rewardPercentiles []float64) (*FeeHistory, error) {
+	var result FeeHistory
+	if err := c.Call(&result, "eth_feeHistory", hexutil.Uint64(blockCount), lastBlock, rewardPercentiles); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SuggestFees returns the base fee of the next block and a priority fee
+// (the average of the median rewards of the last 10 blocks) for dynamic fee
+// txs. Fails if the chain does not support EIP-1559.
+func (c *Client) SuggestFees() (baseFee *uint256.Int, tip *uint256.Int, err error) {
+	history, err := c.FeeHistory(10, "latest", []float64{50})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(history.BaseFee) == 0 || history.BaseFee[len(history.BaseFee)-1] == nil {
+		return nil, nil, errors.New("eth_feeHistory: no base fee (chain does not support EIP-1559)")
+	}
+	baseFee, err = toUint256((*big.Int)(history.BaseFee[len(history.BaseFee)-1]))
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := new(big.Int)
+	count := int64(0)
+	for _, rewards := range history.Reward {
+		if len(rewards) == 0 || rewards[0] == nil {
+			continue
+		}
+		sum.Add(sum, (*big.Int)(rewards[0]))
+		count++
+	}
+	if count > 0 {
+		sum.Div(sum, big.NewInt(count))
+	}
+	tip, err = toUint256(sum)
+	if err != nil {
+		return nil, nil, err
+	}
+	return baseFee, tip, nil
+}
+
+// Receipt contains the fields of eth_getTransactionReceipt result we care about.
+type Receipt struct {
+	TxHash            common.Hash    `json:"transactionHash"`
+	BlockNumber       *hexutil.Big   `json:"blockNumber"`
+	Status            hexutil.Uint64 `json:"status"`
+	GasUsed           hexutil.Uint64 `json:"gasUsed"`
+	EffectiveGasPrice *hexutil.Big   `json:"effectiveGasPrice"`
+}
+
+// SendRawTransaction submits a signed and encoded tx with
+// eth_sendRawTransaction and returns its hash.
+func (c *Client) SendRawTransaction(raw []byte) (common.Hash, error) {
+	var result common.Hash
+	if err := c.Call(&result, "eth_sendRawTransaction", hexutil.Bytes(raw)); err != nil {
+		return common.Hash{}, err
+	}
+	return result, nil
+}
+
+// TransactionReceipt returns the receipt of a mined tx with
+// eth_getTransactionReceipt. Returns ErrNoResult if the tx is not mined yet.
+func (c *Client) TransactionReceipt(hash common.Hash) (*Receipt, error) {
+	var result Receipt
+	if err := c.Call(&result, "eth_getTransactionReceipt", hash); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func toUint256(b *big.Int) (*uint256.Int, error) {
+	v, overflow := uint256.FromBig(b)
+	if overflow {
+		return nil, errors.New("value does not fit into 256 bits")
+	}
+	return v, nil
+}
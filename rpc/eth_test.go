@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+func TestChainID(t *testing.T) {
+	srv, _ := newStubNode(t, map[string]stubReply{
+		"eth_chainId": {result: `"0x89"`},
+	})
+	id, err := NewClient(srv.URL).ChainID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Uint64() != 137 {
+		t.Fatalf("chain id: got %v, want 137", id)
+	}
+}
+
+func TestPendingNonceAt(t *testing.T) {
+	srv, params := newStubNode(t, map[string]stubReply{
+		"eth_getTransactionCount": {result: `"0x2a"`},
+	})
+	addr := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	nonce, err := NewClient(srv.URL).PendingNonceAt(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce != 42 {
+		t.Fatalf("nonce: got %d, want 42", nonce)
+	}
+	var args []string
+	if err := json.Unmarshal(params["eth_getTransactionCount"], &args); err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 2 || common.HexToAddress(args[0]) != addr || args[1] != "pending" {
+		t.Fatalf("unexpected params: %v", args)
+	}
+}
+
+func TestEstimateGas(t *testing.T) {
+	srv, params := newStubNode(t, map[string]stubReply{
+		"eth_estimateGas": {result: `"0x5208"`},
+	})
+	to := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	msg := NewCallMsg(common.HexToAddress("0x00000000000000000000000000000000000000aa"), &to, uint256.NewInt(1000), []byte{0xa9, 0x05, 0x9c, 0xbb})
+	gas, err := NewClient(srv.URL).EstimateGas(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gas != 21000 {
+		t.Fatalf("gas: got %d, want 21000", gas)
+	}
+	var args []map[string]interface{}
+	if err := json.Unmarshal(params["eth_estimateGas"], &args); err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 1 || args[0]["value"] != "0x3e8" || args[0]["data"] != "0xa9059cbb" {
+		t.Fatalf("unexpected params: %v", args)
+	}
+	if _, ok := args[0]["accessList"]; ok {
+		t.Fatalf("empty access list sent: %v", args)
+	}
+}
+
+func TestFeeHistory(t *testing.T) {
+	srv, _ := newStubNode(t, map[string]stubReply{
+		"eth_feeHistory": {result: `{"oldestBlock":"0x10","reward":[["0x1"],["0x3"]],"baseFeePerGas":["0x64","0x65","0x66"],"gasUsedRatio":[0.5,0.6]}`},
+	})
+	history, err := NewClient(srv.URL).FeeHistory(2, "latest", []float64{50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.OldestBlock.ToInt().Uint64() != 16 || len(history.Reward) != 2 || len(history.BaseFee) != 3 {
+		t.Fatalf("unexpected fee history: %+v", history)
+	}
+}
+
+func TestSuggestFees(t *testing.T) {
+	srv, _ := newStubNode(t, map[string]stubReply{
+		"eth_feeHistory": {result: `{"oldestBlock":"0x10","reward":[["0x1"],["0x3"]],"baseFeePerGas":["0x64","0x65","0x66"],"gasUsedRatio":[0.5,0.6]}`},
+	})
+	baseFee, tip, err := NewClient(srv.URL).SuggestFees()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baseFee.Uint64() != 0x66 {
+		t.Fatalf("base fee: got %v, want the next block base fee 0x66", baseFee)
+	}
+	if tip.Uint64() != 2 {
+		t.Fatalf("tip: got %v, want the average reward 2", tip)
+	}
+}
+
+func TestSuggestFeesNoBaseFee(t *testing.T) {
+	srv, _ := newStubNode(t, map[string]stubReply{
+		"eth_feeHistory": {result: `{"oldestBlock":"0x10","reward":[],"gasUsedRatio":[]}`},
+		"eth_gasPrice":   {result: `"0x3b9aca00"`},
+	})
+	client := NewClient(srv.URL)
+	if _, _, err := client.SuggestFees(); err == nil {
+		t.Fatal("expected an error without base fees")
+	}
+	// the caller falls back to the legacy gas price
+	gasPrice, err := client.GasPrice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gasPrice.Uint64() != 1000000000 {
+		t.Fatalf("gas price: got %v", gasPrice)
+	}
+}
+
+func TestSuggestFeesNotSupported(t *testing.T) {
+	srv, _ := newStubNode(t, map[string]stubReply{})
+	_, _, err := NewClient(srv.URL).SuggestFees()
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Fatalf("expected method not found error, got %v", err)
+	}
+}
+
+func TestSendRawTransaction(t *testing.T) {
+	hash := common.HexToHash("0x1234")
+	srv, params := newStubNode(t, map[string]stubReply{
+		"eth_sendRawTransaction": {result: `"` + hash.Hex() + `"`},
+	})
+	got, err := NewClient(srv.URL).SendRawTransaction([]byte{0x02, 0xf8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != hash {
+		t.Fatalf("hash: got %s, want %s", got.Hex(), hash.Hex())
+	}
+	if string(params["eth_sendRawTransaction"]) != `["0x02f8"]` {
+		t.Fatalf("unexpected params: %s", params["eth_sendRawTransaction"])
+	}
+}
+
+func TestTransactionReceipt(t *testing.T) {
+	hash := common.HexToHash("0x1234")
+	srv, _ := newStubNode(t, map[string]stubReply{
+		"eth_getTransactionReceipt": {result: `{"transactionHash":"` + hash.Hex() + `","blockNumber":"0x10","status":"0x1","gasUsed":"0x5208","effectiveGasPrice":"0x3b9aca00"}`},
+	})
+	receipt, err := NewClient(srv.URL).TransactionReceipt(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.TxHash != hash || receipt.Status != 1 || receipt.GasUsed != 21000 || receipt.BlockNumber.ToInt().Uint64() != 16 {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+}
+
+func TestTransactionReceiptPending(t *testing.T) {
+	srv, _ := newStubNode(t, map[string]stubReply{
+		"eth_getTransactionReceipt": {result: "null"},
+	})
+	_, err := NewClient(srv.URL).TransactionReceipt(common.HexToHash("0x1234"))
+	if !errors.Is(err, ErrNoResult) {
+		t.Fatalf("expected ErrNoResult for a pending tx, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/rpc"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/jaanek/jethwallet/wallet"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+const receiptPollInterval = 2 * time.Second
+
+type SendOutput struct {
+	RpcUrl            string  `json:"rpcUrl"`
+	ChainId           string  `json:"chainId"`
+	Hash              string  `json:"hash"`
+	Status            *uint64 `json:"status,omitempty"`
+	BlockNumber       string  `json:"blockNumber,omitempty"`
+	GasUsed           *uint64 `json:"gasUsed,omitempty"`
+	EffectiveGasPrice string  `json:"effectiveGasPrice,omitempty"`
+}
+
+func SendTx(ctx context.Context, term ui.Screen, flag *flags.Flags) error {
+	if flag.FlagRpcUrl == "" {
+		return errors.New("Missing --rpc-url")
+	}
+	signed, err := CreateSignedTx(term, flag)
+	if err != nil {
+		return err
+	}
+	encoded, err := wallet.EncodeTx(signed)
+	if err != nil {
+		return err
+	}
+
+	// broadcast
+	client := rpc.NewClient(flag.FlagRpcUrl)
+	hash, err := client.SendRawTransaction(encoded)
+	if err != nil {
+		return err
+	}
+	term.Logf("tx sent: %s\n", hash.Hex())
+	out := SendOutput{
+		RpcUrl:  flag.FlagRpcUrl,
+		ChainId: flag.FlagChainID,
+		Hash:    hash.Hex(),
+	}
+
+	// wait for the tx to be mined
+	if flag.FlagWait {
+		receipt, err := waitReceipt(ctx, term, client, hash, time.Duration(flag.FlagWaitTimeout)*time.Second)
+		if err != nil {
+			return err
+		}
+		status := uint64(receipt.Status)
+		gasUsed := uint64(receipt.GasUsed)
+		out.Status = &status
+		out.GasUsed = &gasUsed
+		if receipt.BlockNumber != nil {
+			out.BlockNumber = receipt.BlockNumber.String()
+		}
+		if receipt.EffectiveGasPrice != nil {
+			out.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
+		}
+	}
+	outb, err := json.Marshal(&out)
+	if err != nil {
+		return err
+	}
+	term.Result(fmt.Sprintf("%s\n", string(outb)), &out)
+	return nil
+}
+
+// waitReceipt polls the node for the tx receipt until the tx is mined, the
+// timeout expires or ctx is cancelled
+func waitReceipt(ctx context.Context, term ui.Screen, client *rpc.Client, hash common.Hash, timeout time.Duration) (*rpc.Receipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+	for {
+		receipt, err := client.TransactionReceipt(hash)
+		if err == nil {
+			return receipt, nil
+		}
+		if !errors.Is(err, rpc.ErrNoResult) {
+			return nil, err
+		}
+		term.Logf("waiting for tx %s to be mined...\n", hash.Hex())
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no receipt for tx %s: %w", hash.Hex(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
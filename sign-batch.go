@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/hwwallet"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/keystore"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/jaanek/jethwallet/wallet"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+const maxBatchLineSize = 10 * 1024 * 1024
+
+type BatchOutput struct {
+	Line           int    `json:"line"`
+	From           string `json:"from,omitempty"`
+	Nonce          string `json:"nonce,omitempty"`
+	RpcUrl         string `json:"rpcUrl,omitempty"`
+	ChainId        string `json:"chainId,omitempty"`
+	RawTransaction string `json:"tx,omitempty"`
+	TransactionSig string `json:"txsig,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// batchSigner is a keystore or hw wallet session kept open for all txs
+type batchSigner interface {
+	SignTx(fromAddr common.Address, tx types.Transaction) (types.Transaction, error)
+}
+
+// SignBatch signs txs read from JSONL input, one StdInput object per line.
+// Missing nonces are incremented from the previous tx of the same sender.
+// Outputs one JSON line per tx, failed txs are reported with an error.
+func SignBatch(term ui.Screen, flag *flags.Flags) error {
+	var reader io.Reader = os.Stdin
+	if flag.FlagFile != "" {
+		f, err := os.Open(flag.FlagFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	// open the wallet once for all txs
+	var signer batchSigner
+	if flag.KeystorePath != "" {
+		session := keystore.NewSession(term, flag.KeystorePath)
+		defer session.Close()
+		signer = session
+	} else {
+		descriptors, err := ledgerDescriptors(flag)
+		if err != nil {
+			return err
+		}
+		session, err := hwwallet.NewSession(term, hwcommon.GetWalletTypeFromFlags(flag), flag.Max)
+		if err != nil {
+			return err
+		}
+		session.SetDescriptors(descriptors)
+		signer = session
+	}
+
+	nonces := make(map[common.Address]uint64)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxBatchLineSize)
+	lineNr, failed, total := 0, 0, 0
+	for scanner.Scan() {
+		lineNr++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		total++
+		out := signBatchLine(term, flag, signer, nonces, line)
+		out.Line = lineNr
+		if out.Error != "" {
+			failed++
+		}
+		outb, err := json.Marshal(&out)
+		if err != nil {
+			return err
+		}
+		term.Result(fmt.Sprintf("%s\n", string(outb)), &out)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.New(fmt.Sprintf("%d of %d txs failed", failed, total))
+	}
+	return nil
+}
+
+func signBatchLine(term ui.Screen, flag *flags.Flags, signer batchSigner, nonces map[common.Address]uint64, line string) BatchOutput {
+	input := StdInput{}
+	if err := json.Unmarshal([]byte(line), &input); err != nil {
+		return BatchOutput{Error: fmt.Sprintf("invalid json: %v", err)}
+	}
+	txFlag := *flag
+	input.ApplyTo(&txFlag)
+	out := BatchOutput{From: txFlag.FlagFrom}
+	if txFlag.FlagFrom == "" {
+		out.Error = "Missing from address"
+		return out
+	}
+	fromAddr := common.HexToAddress(txFlag.FlagFrom)
+	if next, ok := nonces[fromAddr]; ok && input.TxCount == "" {
+		txFlag.FlagNonce = strconv.FormatUint(next, 10)
+	}
+	tx, _, err := CreateTx(term, &txFlag)
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	out.Nonce = strconv.FormatUint(tx.GetNonce(), 10)
+	out.RpcUrl = txFlag.FlagRpcUrl
+	out.ChainId = txFlag.FlagChainID
+	signed, err := signer.SignTx(fromAddr, tx)
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	encoded, err := wallet.EncodeTx(signed)
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	nonces[fromAddr] = tx.GetNonce() + 1
+	v, r, s := signed.RawSignatureValues()
+	out.RawTransaction = hexutil.Encode(encoded)
+	out.TransactionSig = fmt.Sprintf("0x%064x%064x%02x", r, s, v)
+	return out
+}
@@ -14,6 +14,10 @@ import (
 	"github.com/ledgerwatch/erigon/common/hexutil"
 )
 
+type SignatureOutput struct {
+	Signature string `json:"signature"`
+}
+
 func SignMsg(term ui.Screen, flag *flags.Flags) error {
 	if flag.FlagFrom == "" {
 		return errors.New("Missing --from address")
@@ -26,23 +30,24 @@ func SignMsg(term ui.Screen, flag *flags.Flags) error {
 	if strings.HasPrefix(flag.FlagInput, "0x") {
 		data = hexutil.MustDecode(flag.FlagInput)
 	}
-	var msg []byte = data
-	if flag.FlagAddEthPrefix {
-		msg = []byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(data), data))
-	}
 
-	// sign message
+	// sign message. Hw wallets always add the Ethereum signature prefix on the
+	// device, so --with-eth-prefix applies to keystore only
 	var signature []byte
 	var err error
 	if flag.KeystorePath != "" {
+		var msg []byte = data
+		if flag.FlagAddEthPrefix {
+			msg = []byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(data), data))
+		}
 		signature, err = keystore.SignMsg(term, flag.KeystorePath, fromAddr, msg)
 	} else {
 		hwWalletType := hwcommon.GetWalletTypeFromFlags(flag)
-		signature, err = hwwallet.SignMsg(term, hwWalletType, fromAddr, msg, flag.Max)
+		signature, err = hwwallet.SignMsg(term, hwWalletType, fromAddr, data, flag.Max)
 	}
 	if err != nil {
 		return fmt.Errorf("Error while signing message: %w", err)
 	}
-	term.Output(hexutil.Encode(signature[:]))
+	term.Result(hexutil.Encode(signature[:]), &SignatureOutput{Signature: hexutil.Encode(signature[:])})
 	return nil
 }
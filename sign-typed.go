@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/hwwallet"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/keystore"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/jaanek/jethwallet/wallet"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+)
+
+func SignTypedMsg(term ui.Screen, flag *flags.Flags) error {
+	if flag.FlagFrom == "" {
+		return errors.New("Missing --from address")
+	}
+	if flag.FlagInput == "" && flag.FlagFile == "" {
+		return errors.New("Either --data or --file must be provided")
+	}
+	fromAddr := common.HexToAddress(flag.FlagFrom)
+	data := []byte(flag.FlagInput)
+	if flag.FlagFile != "" {
+		var err error
+		data, err = ioutil.ReadFile(flag.FlagFile)
+		if err != nil {
+			return err
+		}
+	}
+	typedData, err := wallet.ParseTypedData(data)
+	if err != nil {
+		return err
+	}
+	domainHash, messageHash, hash, err := wallet.TypedDataAndHash(typedData)
+	if err != nil {
+		return fmt.Errorf("Error while hashing typed data: %w", err)
+	}
+	term.Logf("domain hash: %x, message hash: %x, hash: %x\n", domainHash, messageHash, hash)
+
+	// sign typed data
+	var signature []byte
+	if flag.KeystorePath != "" {
+		signature, err = keystore.SignTypedMsg(term, flag.KeystorePath, fromAddr, hash)
+	} else {
+		hwWalletType := hwcommon.GetWalletTypeFromFlags(flag)
+		signature, err = hwwallet.SignTypedMsg(term, hwWalletType, fromAddr, domainHash, messageHash, flag.Max)
+	}
+	if err != nil {
+		return fmt.Errorf("Error while signing typed data: %w", err)
+	}
+	term.Result(hexutil.Encode(signature[:]), &SignatureOutput{Signature: hexutil.Encode(signature[:])})
+	return nil
+}
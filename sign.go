@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"strings"
 
 	"github.com/holiman/uint256"
@@ -11,6 +12,8 @@ import (
 	"github.com/jaanek/jethwallet/hwwallet"
 	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
 	"github.com/jaanek/jethwallet/keystore"
+	"github.com/jaanek/jethwallet/ledger"
+	"github.com/jaanek/jethwallet/rpc"
 	"github.com/jaanek/jethwallet/ui"
 	"github.com/jaanek/jethwallet/wallet"
 	"github.com/ledgerwatch/erigon/accounts/abi"
@@ -29,29 +32,126 @@ type Output struct {
 }
 
 func SignTx(term ui.Screen, flag *flags.Flags) error {
-	// validate flags
-	if flag.FlagNonce == "" {
-		return errors.New("Missing --nonce")
+	signed, err := CreateSignedTx(term, flag)
+	if err != nil {
+		return err
+	}
+
+	// output
+	encoded, err := wallet.EncodeTx(signed)
+	encodedHex := hexutil.Encode(encoded)
+	v, r, s := signed.RawSignatureValues()
+	txSig := fmt.Sprintf("0x%064x%064x%02x", r, s, v)
+	out := Output{
+		RpcUrl:         flag.FlagRpcUrl,
+		ChainId:        flag.FlagChainID,
+		RawTransaction: encodedHex,
+		TransactionSig: txSig,
+	}
+	outb, err := json.Marshal(&out)
+	if err != nil {
+		return err
 	}
+	term.Result(fmt.Sprintf("%s\n", string(outb)), &out)
+	return nil
+}
+
+// CreateSignedTx builds a tx from flags and signs it with the selected wallet
+func CreateSignedTx(term ui.Screen, flag *flags.Flags) (types.Transaction, error) {
+	tx, fromAddr, err := CreateTx(term, flag)
+	if err != nil {
+		return nil, err
+	}
+
+	// sign tx
+	var signed types.Transaction
+	if flag.KeystorePath != "" {
+		signed, err = keystore.SignTx(term, flag.KeystorePath, fromAddr, tx)
+	} else {
+		var descriptors *ledger.Descriptors
+		descriptors, err = ledgerDescriptors(flag)
+		if err != nil {
+			return nil, err
+		}
+		hwWalletType := hwcommon.GetWalletTypeFromFlags(flag)
+		signed, err = hwwallet.SignTx(term, hwWalletType, fromAddr, tx, descriptors, flag.Max)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+// ledgerDescriptors loads the --ledger-descriptors file if provided
+func ledgerDescriptors(flag *flags.Flags) (*ledger.Descriptors, error) {
+	if flag.FlagDescriptors == "" {
+		return nil, nil
+	}
+	if !flag.UseLedger {
+		return nil, errors.New("--ledger-descriptors can be used with --ledger only")
+	}
+	return ledger.LoadDescriptors(flag.FlagDescriptors)
+}
+
+// CreateTx builds an unsigned tx from flags. Missing params are filled in from
+// --rpc-url node if provided.
+func CreateTx(term ui.Screen, flag *flags.Flags) (types.Transaction, common.Address, error) {
+	// node used to fill in the missing tx params
+	var client *rpc.Client
+	if flag.FlagRpcUrl != "" {
+		client = rpc.NewClient(flag.FlagRpcUrl)
+	}
+
+	// validate flags
 	if flag.FlagFrom == "" {
-		return errors.New("Missing --from address")
+		return nil, common.Address{}, errors.New("Missing --from address")
 	}
 	var to *common.Address
 	if flag.FlagTo != "" {
 		t := common.HexToAddress(flag.FlagTo)
 		to = &t
 	}
-	if flag.FlagGasLimit == "" {
-		return errors.New("Missing --gas-limit")
+	if flag.FlagNonce == "" && client == nil {
+		return nil, common.Address{}, errors.New("Missing --nonce")
+	}
+	if flag.FlagGasLimit == "" && client == nil {
+		return nil, common.Address{}, errors.New("Missing --gas-limit")
+	}
+	if flag.FlagCreateAccessList && client == nil {
+		return nil, common.Address{}, errors.New("--create-access-list needs --rpc-url")
+	}
+	accessListSources := countNonEmpty(flag.FlagAccessList, flag.FlagAccessListFile)
+	if flag.FlagCreateAccessList {
+		accessListSources++
+	}
+	if accessListSources > 1 {
+		return nil, common.Address{}, errors.New("Provide only one of --access-list, --access-list-file or --create-access-list")
+	}
+	txType, err := wallet.ParseTxType(flag.FlagTxType)
+	if err != nil {
+		return nil, common.Address{}, err
 	}
-	nonce := math.MustParseUint64(flag.FlagNonce)
 	fromAddr := common.HexToAddress(flag.FlagFrom)
-	gasLimit := math.MustParseUint64(flag.FlagGasLimit)
+	var nonce uint64
+	if flag.FlagNonce != "" {
+		var ok bool
+		nonce, ok = math.ParseUint64(flag.FlagNonce)
+		if !ok {
+			return nil, common.Address{}, errors.New(fmt.Sprintf("nonce not uint64: %v", flag.FlagNonce))
+		}
+	} else {
+		var err error
+		nonce, err = client.PendingNonceAt(fromAddr)
+		if err != nil {
+			return nil, common.Address{}, err
+		}
+		term.Logf("nonce from rpc: %d\n", nonce)
+	}
 	var gasPrice, gasTipCap, gasFeeCap *uint256.Int
 	if flag.FlagGasPrice != "" {
 		gp, ok := math.ParseUint64(flag.FlagGasPrice)
 		if !ok {
-			return errors.New(fmt.Sprintf("gas price not uint64: %v", flag.FlagGasPrice))
+			return nil, common.Address{}, errors.New(fmt.Sprintf("gas price not uint64: %v", flag.FlagGasPrice))
 		}
 		gasPrice = new(uint256.Int).SetUint64(gp)
 		if flag.FlagGasPriceGwei {
@@ -61,7 +161,7 @@ func SignTx(term ui.Screen, flag *flags.Flags) error {
 	if flag.FlagGasTip != "" {
 		gt, ok := math.ParseUint64(flag.FlagGasTip)
 		if !ok {
-			return errors.New(fmt.Sprintf("gas tip not uint64: %v", flag.FlagGasTip))
+			return nil, common.Address{}, errors.New(fmt.Sprintf("gas tip not uint64: %v", flag.FlagGasTip))
 		}
 		gasTipCap = new(uint256.Int).SetUint64(gt)
 		if flag.FlagGasTipGwei {
@@ -71,7 +171,7 @@ func SignTx(term ui.Screen, flag *flags.Flags) error {
 	if flag.FlagGasFeeCap != "" {
 		gfc, ok := math.ParseUint64(flag.FlagGasFeeCap)
 		if !ok {
-			return errors.New(fmt.Sprintf("gas tip fee cap not uint64: %v", flag.FlagGasFeeCap))
+			return nil, common.Address{}, errors.New(fmt.Sprintf("gas tip fee cap not uint64: %v", flag.FlagGasFeeCap))
 		}
 		gasFeeCap = new(uint256.Int).SetUint64(gfc)
 		if flag.FlagGasFeeCapGwei {
@@ -79,14 +179,21 @@ func SignTx(term ui.Screen, flag *flags.Flags) error {
 		}
 	}
 	if gasPrice == nil && (gasTipCap == nil || gasFeeCap == nil) {
-		return errors.New("Either --gas-price or (--gas-tip and --gas-maxfee) must be provided")
+		if client == nil {
+			return nil, common.Address{}, errors.New("Either --gas-price or (--gas-tip and --gas-maxfee) must be provided")
+		}
+		legacy := txType == types.LegacyTxType || txType == types.AccessListTxType
+		gasPrice, gasTipCap, gasFeeCap, err = suggestFees(term, client, gasTipCap, gasFeeCap, legacy)
+		if err != nil {
+			return nil, common.Address{}, err
+		}
 	}
 	var value *uint256.Int
 	if flag.FlagValue != "" {
 		var err error
 		value, err = uint256.FromHex(flag.FlagValue)
 		if err != nil {
-			return errors.New(fmt.Sprintf("invalid 256 bit integer: " + flag.FlagValue))
+			return nil, common.Address{}, errors.New(fmt.Sprintf("invalid 256 bit integer: " + flag.FlagValue))
 		}
 		if flag.FlagValueEth {
 			value = new(uint256.Int).Mul(value, new(uint256.Int).SetUint64(params.Ether))
@@ -97,20 +204,42 @@ func SignTx(term ui.Screen, flag *flags.Flags) error {
 		value = new(uint256.Int)
 	}
 	if flag.FlagChainID == "" {
-		return errors.New("Missing --chain-id")
+		if client == nil {
+			return nil, common.Address{}, errors.New("Missing --chain-id")
+		}
+		id, err := client.ChainID()
+		if err != nil {
+			return nil, common.Address{}, err
+		}
+		flag.FlagChainID = id.Hex()
+		term.Logf("chain id from rpc: %v\n", id)
 	}
 	chainID, err := uint256.FromHex(flag.FlagChainID)
 	if err != nil {
-		return err
+		return nil, common.Address{}, err
+	}
+	if to == nil && flag.FlagInput == "" && flag.FlagMethod == "" {
+		return nil, common.Address{}, errors.New("Either --to, --input or --method must be provided")
 	}
-	if to == nil && flag.FlagInput == "" {
-		return errors.New("Either --to or --input must be provided")
+	if flag.FlagInput != "" && flag.FlagMethod != "" {
+		return nil, common.Address{}, errors.New("Provide either --input or --method, not both")
 	}
 	var methodName string
 	var unpackedInput = []interface{}{}
 	var input = []byte{}
-	if flag.FlagInput != "" {
-		input = hexutil.MustDecode(flag.FlagInput)
+	var call *wallet.DecodedCall
+	if flag.FlagMethod != "" {
+		call, err = EncodeCallData(flag)
+		if err != nil {
+			return nil, common.Address{}, err
+		}
+		input = call.Input
+		term.Logf("call data: %x\n", input)
+	} else if flag.FlagInput != "" {
+		input, err = hexutil.Decode(flag.FlagInput)
+		if err != nil {
+			return nil, common.Address{}, fmt.Errorf("invalid input: %w", err)
+		}
 		if flag.FlagInputMethod != "" {
 			split := strings.Split(flag.FlagInputMethod, ":")
 			if len(split) == 2 {
@@ -128,11 +257,47 @@ func SignTx(term ui.Screen, flag *flags.Flags) error {
 			}
 		}
 	}
+	var accessList types.AccessList
+	if flag.FlagAccessList != "" {
+		accessList, err = wallet.ParseAccessList([]byte(flag.FlagAccessList))
+	} else if flag.FlagAccessListFile != "" {
+		var data []byte
+		data, err = ioutil.ReadFile(flag.FlagAccessListFile)
+		if err == nil {
+			accessList, err = wallet.ParseAccessList(data)
+		}
+	} else if flag.FlagCreateAccessList {
+		var result *rpc.AccessListResult
+		result, err = client.CreateAccessList(rpc.NewCallMsg(fromAddr, to, value, input))
+		if err == nil {
+			accessList = result.AccessList
+			term.Logf("access list from rpc: %d addresses, gas used: %d\n", len(accessList), result.GasUsed)
+		}
+	}
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	var gasLimit uint64
+	if flag.FlagGasLimit != "" {
+		var ok bool
+		gasLimit, ok = math.ParseUint64(flag.FlagGasLimit)
+		if !ok {
+			return nil, common.Address{}, errors.New(fmt.Sprintf("gas limit not uint64: %v", flag.FlagGasLimit))
+		}
+	} else {
+		msg := rpc.NewCallMsg(fromAddr, to, value, input)
+		msg.AccessList = accessList
+		gasLimit, err = client.EstimateGas(msg)
+		if err != nil {
+			return nil, common.Address{}, err
+		}
+		term.Logf("gas limit from rpc: %d\n", gasLimit)
+	}
 
 	// Create the transaction to sign
-	tx, err := wallet.NewTx(*chainID, nonce, to, value, input, gasLimit, gasPrice, gasTipCap, gasFeeCap)
+	tx, err := wallet.NewTypedTx(txType, *chainID, nonce, to, value, input, gasLimit, gasPrice, gasTipCap, gasFeeCap, accessList)
 	if err != nil {
-		return err
+		return nil, common.Address{}, err
 	}
 
 	if flag.Plain {
@@ -141,13 +306,21 @@ func SignTx(term ui.Screen, flag *flags.Flags) error {
 		term.Print("**************************")
 		valueInGwei := new(uint256.Int).Div(value, new(uint256.Int).SetUint64(params.GWei))
 		term.Print(fmt.Sprintf("rpcUrl: %s", flag.FlagRpcUrl))
+		term.Print(fmt.Sprintf("type: %d", tx.Type()))
 		term.Print(fmt.Sprintf("chainId: %v", chainID))
 		term.Print(fmt.Sprintf("nonce: %v", nonce))
 		term.Print(fmt.Sprintf("from: %s", fromAddr))
 		term.Print(fmt.Sprintf("to: %s", to))
 		term.Print(fmt.Sprintf("value: %s wei (%s gwei) (%.9f eth/ftm)", value, valueInGwei, float64(valueInGwei.Uint64())/1e9))
 		term.Print(fmt.Sprintf("data: %x", input))
-		term.Print(fmt.Sprintf("method: %s, args:: %+v", methodName, unpackedInput))
+		if call == nil && methodName == "" && len(input) >= 4 {
+			call = decodeInput(term, flag, input)
+		}
+		if call != nil {
+			printCall(term, client, chainID, to, call)
+		} else {
+			term.Print(fmt.Sprintf("method: %s, args:: %+v", methodName, unpackedInput))
+		}
 		term.Print(fmt.Sprintf("gas: %v", gasLimit))
 		if gasTipCap != nil {
 			gasTipInGwei := new(uint256.Int).Div(gasTipCap, new(uint256.Int).SetUint64(params.GWei))
@@ -157,39 +330,13 @@ func SignTx(term ui.Screen, flag *flags.Flags) error {
 			gasPriceInGwei := new(uint256.Int).Div(gasPrice, new(uint256.Int).SetUint64(params.GWei))
 			term.Print(fmt.Sprintf("gasPrice: %s wei (%s gwei)", gasPrice, gasPriceInGwei))
 		}
+		for _, tuple := range tx.GetAccessList() {
+			term.Print(fmt.Sprintf("accessList: %s %d storage keys", tuple.Address.Hex(), len(tuple.StorageKeys)))
+		}
 		term.Print("*** Press ENTER to continue! ***")
 		term.ReadPassword()
 	}
-
-	// sign tx
-	var signed types.Transaction
-	if flag.KeystorePath != "" {
-		signed, err = keystore.SignTx(term, flag.KeystorePath, fromAddr, tx)
-	} else {
-		hwWalletType := hwcommon.GetWalletTypeFromFlags(flag)
-		signed, err = hwwallet.SignTx(term, hwWalletType, fromAddr, tx, flag.Max)
-	}
-	if err != nil {
-		return err
-	}
-
-	// output
-	encoded, err := wallet.EncodeTx(signed)
-	encodedHex := hexutil.Encode(encoded)
-	v, r, s := signed.RawSignatureValues()
-	txSig := fmt.Sprintf("0x%064x%064x%02x", r, s, v)
-	out := Output{
-		RpcUrl:         flag.FlagRpcUrl,
-		ChainId:        flag.FlagChainID,
-		RawTransaction: encodedHex,
-		TransactionSig: txSig,
-	}
-	outb, err := json.Marshal(&out)
-	if err != nil {
-		return err
-	}
-	term.Output(fmt.Sprintf("%s\n", string(outb)))
-	return nil
+	return tx, fromAddr, nil
 }
 
 func AbiTypesFromStrings(typeNames []string) (abi.Arguments, error) {
@@ -209,3 +356,103 @@ func AbiTypesFromStrings(typeNames []string) (abi.Arguments, error) {
 	}
 	return argTypes, nil
 }
+
+// EncodeCallData encodes tx input from --method, --args and optional --abi
+// flags. Returns the encoded call with the method and parsed args.
+func EncodeCallData(flag *flags.Flags) (*wallet.DecodedCall, error) {
+	var method abi.Method
+	if flag.FlagAbi != "" {
+		contractAbi, err := wallet.LoadABI(flag.FlagAbi)
+		if err != nil {
+			return nil, err
+		}
+		method, err = wallet.FindMethod(contractAbi, flag.FlagMethod)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if !strings.Contains(flag.FlagMethod, "(") {
+			return nil, fmt.Errorf("--method %s must be a full signature like transfer(address,uint256) or used with --abi", flag.FlagMethod)
+		}
+		var err error
+		method, err = wallet.ParseMethodSig(flag.FlagMethod)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args, err := wallet.SplitArgs(flag.FlagArgs)
+	if err != nil {
+		return nil, err
+	}
+	input, values, err := wallet.EncodeCall(method, args)
+	if err != nil {
+		return nil, err
+	}
+	return &wallet.DecodedCall{Method: method, Args: values, Input: input}, nil
+}
+
+// suggestFees fills in the missing fee params from the node. A dynamic fee tx
+// is suggested when the chain supports EIP-1559 and legacy is not requested,
+// otherwise a legacy tx with eth_gasPrice. A provided gas tip or fee cap is
+// never dropped: without eth_feeHistory the missing one is taken from
+// eth_gasPrice, and with legacy requested it is an error.
+func suggestFees(term ui.Screen, client *rpc.Client, gasTipCap, gasFeeCap *uint256.Int, legacy bool) (gasPrice, tip, feeCap *uint256.Int, err error) {
+	userFees := gasTipCap != nil || gasFeeCap != nil
+	if legacy && userFees {
+		return nil, nil, nil, errors.New("--gastip and --gasfeecap are for a dynamic fee (type 2) tx, use --gasprice for a type 0 or 1 tx")
+	}
+	var baseFee, suggestedTip *uint256.Int
+	if !legacy {
+		baseFee, suggestedTip, err = client.SuggestFees()
+	}
+	if legacy || err != nil {
+		if err != nil {
+			term.Logf("No dynamic fees from rpc (%v), using eth_gasPrice\n", err)
+		}
+		gasPrice, err = client.GasPrice()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		term.Logf("gas price from rpc: %v\n", gasPrice)
+		if !userFees {
+			return gasPrice, nil, nil, nil
+		}
+		// keep the dynamic fee tx of the provided fees
+		tip, feeCap = gasTipCap, gasFeeCap
+		if tip == nil {
+			tip = gasPrice
+			if feeCap != nil && feeCap.Lt(tip) {
+				tip = feeCap
+			}
+		}
+		if feeCap == nil {
+			feeCap = gasPrice
+			if feeCap.Lt(tip) {
+				feeCap = tip
+			}
+		}
+		term.Logf("gas tip: %v, gas fee cap: %v\n", tip, feeCap)
+		return nil, tip, feeCap, nil
+	}
+	tip = gasTipCap
+	if tip == nil {
+		tip = suggestedTip
+	}
+	feeCap = gasFeeCap
+	if feeCap == nil {
+		// leave room for the base fee to double
+		feeCap = new(uint256.Int).Add(new(uint256.Int).Mul(baseFee, uint256.NewInt(2)), tip)
+	}
+	term.Logf("base fee from rpc: %v, gas tip: %v, gas fee cap: %v\n", baseFee, tip, feeCap)
+	return nil, tip, feeCap, nil
+}
+
+func countNonEmpty(values ...string) int {
+	count := 0
+	for _, v := range values {
+		if v != "" {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/jaanek/jethwallet/rpc"
+	"github.com/jaanek/jethwallet/ui"
+)
+
+// newFeeNode starts a node replying eth_gasPrice with 1 gwei and eth_feeHistory
+// with a base fee of 100 wei and a tip of 2 wei, or a method not found error
+// without dynamic fees
+func newFeeNode(t *testing.T, dynamicFees bool) *rpc.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch {
+		case req.Method == "eth_gasPrice":
+			reply["result"] = "0x3b9aca00"
+		case req.Method == "eth_feeHistory" && dynamicFees:
+			reply["result"] = json.RawMessage(`{"oldestBlock":"0x1","reward":[["0x2"]],"baseFeePerGas":["0x64","0x64"],"gasUsedRatio":[0.5]}`)
+		default:
+			reply["error"] = map[string]interface{}{"code": -32601, "message": "the method does not exist"}
+		}
+		json.NewEncoder(w).Encode(reply)
+	}))
+	t.Cleanup(srv.Close)
+	return rpc.NewClient(srv.URL)
+}
+
+func TestSuggestFeesDynamic(t *testing.T) {
+	gasPrice, tip, feeCap, err := suggestFees(ui.NewTerminal(false), newFeeNode(t, true), nil, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gasPrice != nil || tip.Uint64() != 2 || feeCap.Uint64() != 202 {
+		t.Fatalf("unexpected fees: gas price %v, tip %v, fee cap %v", gasPrice, tip, feeCap)
+	}
+}
+
+func TestSuggestFeesFallback(t *testing.T) {
+	gasPrice, tip, feeCap, err := suggestFees(ui.NewTerminal(false), newFeeNode(t, false), nil, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gasPrice.Uint64() != 1000000000 || tip != nil || feeCap != nil {
+		t.Fatalf("unexpected fees: gas price %v, tip %v, fee cap %v", gasPrice, tip, feeCap)
+	}
+}
+
+func TestSuggestFeesFallbackKeepsUserTip(t *testing.T) {
+	_, tip, feeCap, err := suggestFees(ui.NewTerminal(false), newFeeNode(t, false), uint256.NewInt(5), nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip == nil || tip.Uint64() != 5 || feeCap == nil || feeCap.Uint64() != 1000000000 {
+		t.Fatalf("user tip not kept: tip %v, fee cap %v", tip, feeCap)
+	}
+}
+
+func TestSuggestFeesFallbackKeepsUserFeeCap(t *testing.T) {
+	_, tip, feeCap, err := suggestFees(ui.NewTerminal(false), newFeeNode(t, false), nil, uint256.NewInt(7), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip == nil || tip.Uint64() != 7 || feeCap == nil || feeCap.Uint64() != 7 {
+		t.Fatalf("user fee cap not kept: tip %v, fee cap %v", tip, feeCap)
+	}
+}
+
+func TestSuggestFeesLegacyRejectsUserTip(t *testing.T) {
+	if _, _, _, err := suggestFees(ui.NewTerminal(false), newFeeNode(t, true), uint256.NewInt(5), nil, true); err == nil {
+		t.Fatal("expected an error for a gas tip with a legacy tx")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/trezor"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+)
+
+type IdentityOutput struct {
+	Address   string `json:"address,omitempty"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+type SessionKeyOutput struct {
+	SessionKey string `json:"sessionKey"`
+	PublicKey  string `json:"publicKey,omitempty"`
+}
+
+// TrezorSignIdentity signs a login challenge with the key of an identity
+func TrezorSignIdentity(term ui.Screen, flag *flags.Flags) error {
+	if flag.FlagIdentity == "" {
+		return errors.New("Missing --identity")
+	}
+	identity, err := trezor.ParseIdentity(flag.FlagIdentity, uint32(flag.FlagIdentityIndex))
+	if err != nil {
+		return err
+	}
+	challenge := []byte(flag.FlagChallenge)
+	if strings.HasPrefix(flag.FlagChallenge, "0x") {
+		if challenge, err = hexutil.Decode(flag.FlagChallenge); err != nil {
+			return fmt.Errorf("Invalid --challenge: %w", err)
+		}
+	}
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	signed, err := device.SignIdentity(identity, challenge, flag.FlagVisual, flag.FlagCurve)
+	if err != nil {
+		return err
+	}
+	out := &IdentityOutput{
+		Address:   signed.GetAddress(),
+		PublicKey: hexutil.Encode(signed.GetPublicKey()),
+		Signature: hexutil.Encode(signed.GetSignature()),
+	}
+	term.Result(fmt.Sprintf("%s\n", out.Signature), out)
+	return nil
+}
+
+// TrezorECDH derives a shared secret of an identity key and a peer public key
+func TrezorECDH(term ui.Screen, flag *flags.Flags) error {
+	if flag.FlagIdentity == "" {
+		return errors.New("Missing --identity")
+	}
+	if flag.FlagPeerKey == "" {
+		return errors.New("Missing --peer public key")
+	}
+	identity, err := trezor.ParseIdentity(flag.FlagIdentity, uint32(flag.FlagIdentityIndex))
+	if err != nil {
+		return err
+	}
+	peerKey, err := hexutil.Decode(flag.FlagPeerKey)
+	if err != nil {
+		return fmt.Errorf("Invalid --peer: %w", err)
+	}
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	sessionKey, err := device.GetECDHSessionKey(identity, peerKey, flag.FlagCurve)
+	if err != nil {
+		return err
+	}
+	out := &SessionKeyOutput{
+		SessionKey: hexutil.Encode(sessionKey.GetSessionKey()),
+		PublicKey:  hexutil.Encode(sessionKey.GetPublicKey()),
+	}
+	if len(sessionKey.GetPublicKey()) == 0 {
+		out.PublicKey = ""
+	}
+	term.Result(fmt.Sprintf("%s\n", out.SessionKey), out)
+	return nil
+}
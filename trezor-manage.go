@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/trezor"
+	"github.com/jaanek/jethwallet/trezor/trezorproto"
+	"github.com/jaanek/jethwallet/ui"
+)
+
+type PingOutput struct {
+	Message string `json:"message"`
+}
+
+func TrezorFeatures(term ui.Screen, flag *flags.Flags) error {
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	features, err := device.Features()
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(features, "", "  ")
+	if err != nil {
+		return err
+	}
+	term.Result(fmt.Sprintf("%s\n", out), features)
+	return nil
+}
+
+func TrezorSettings(term ui.Screen, flag *flags.Flags) error {
+	settings := &trezorproto.ApplySettings{}
+	if flag.FlagLabel != "" {
+		settings.Label = &flag.FlagLabel
+	}
+	usePassphrase, err := parsePassphraseFlag(flag)
+	if err != nil {
+		return err
+	}
+	settings.UsePassphrase = usePassphrase
+	if settings.Label == nil && settings.UsePassphrase == nil {
+		return errors.New("Missing --label or --passphrase")
+	}
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	if err := device.ApplySettings(settings); err != nil {
+		return err
+	}
+	term.Result("Settings applied\n", nil)
+	return nil
+}
+
+// TrezorReset creates a new seed on an uninitialized device
+func TrezorReset(term ui.Screen, flag *flags.Flags) error {
+	if flag.FlagStrength != 128 && flag.FlagStrength != 192 && flag.FlagStrength != 256 {
+		return errors.New(fmt.Sprintf("Invalid --strength: %d, use 128, 192 or 256", flag.FlagStrength))
+	}
+	usePassphrase, err := parsePassphraseFlag(flag)
+	if err != nil {
+		return err
+	}
+	strength := uint32(flag.FlagStrength)
+	settings := &trezorproto.ResetDevice{
+		Strength:             &strength,
+		PassphraseProtection: usePassphrase,
+		PinProtection:        &flag.FlagPin,
+		SkipBackup:           &flag.FlagSkipBackup,
+	}
+	if flag.FlagLabel != "" {
+		settings.Label = &flag.FlagLabel
+	}
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	if err := device.ResetDevice(settings); err != nil {
+		return err
+	}
+	if flag.FlagSkipBackup {
+		term.Result("Device initialized, backup the seed with: trezor backup\n", nil)
+	} else {
+		term.Result("Device initialized\n", nil)
+	}
+	return nil
+}
+
+// TrezorBackup shows the seed words of a device initialized with --skip-backup
+func TrezorBackup(term ui.Screen, flag *flags.Flags) error {
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	if err := device.BackupDevice(); err != nil {
+		return err
+	}
+	term.Result("Backup done\n", nil)
+	return nil
+}
+
+// TrezorRecover restores a seed on an uninitialized device, or with --dry-run
+// checks the words match the seed of the device
+func TrezorRecover(term ui.Screen, flag *flags.Flags) error {
+	if flag.FlagWordCount != 12 && flag.FlagWordCount != 18 && flag.FlagWordCount != 24 {
+		return errors.New(fmt.Sprintf("Invalid --words: %d, use 12, 18 or 24", flag.FlagWordCount))
+	}
+	usePassphrase, err := parsePassphraseFlag(flag)
+	if err != nil {
+		return err
+	}
+	wordCount := uint32(flag.FlagWordCount)
+	recoveryType := trezorproto.RecoveryDevice_RecoveryDeviceType_ScrambledWords
+	if flag.FlagMatrix {
+		recoveryType = trezorproto.RecoveryDevice_RecoveryDeviceType_Matrix
+	}
+	enforceWordlist := true
+	settings := &trezorproto.RecoveryDevice{
+		WordCount:       &wordCount,
+		EnforceWordlist: &enforceWordlist,
+		Type:            &recoveryType,
+		DryRun:          &flag.FlagDryRun,
+	}
+	if !flag.FlagDryRun {
+		settings.PassphraseProtection = usePassphrase
+		settings.PinProtection = &flag.FlagPin
+		if flag.FlagLabel != "" {
+			settings.Label = &flag.FlagLabel
+		}
+	}
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	if err := device.RecoveryDevice(settings); err != nil {
+		return err
+	}
+	if flag.FlagDryRun {
+		term.Result("Recovery words match the device seed\n", nil)
+	} else {
+		term.Result("Device recovered\n", nil)
+	}
+	return nil
+}
+
+func TrezorChangePin(term ui.Screen, flag *flags.Flags) error {
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	if err := device.ChangePin(flag.FlagRemove); err != nil {
+		return err
+	}
+	if flag.FlagRemove {
+		term.Result("PIN removed\n", nil)
+	} else {
+		term.Result("PIN changed\n", nil)
+	}
+	return nil
+}
+
+func TrezorChangeWipeCode(term ui.Screen, flag *flags.Flags) error {
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	if err := device.ChangeWipeCode(flag.FlagRemove); err != nil {
+		return err
+	}
+	if flag.FlagRemove {
+		term.Result("Wipe code removed\n", nil)
+	} else {
+		term.Result("Wipe code changed\n", nil)
+	}
+	return nil
+}
+
+// TrezorWipe erases the device. Needs --yes and a confirmation on the device.
+func TrezorWipe(term ui.Screen, flag *flags.Flags) error {
+	if !flag.FlagYes {
+		return errors.New("Wiping erases the seed from device, make sure it has a backup and confirm with --yes")
+	}
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	term.Print(fmt.Sprintf("*** NB! Wiping Trezor '%s', confirm on device to erase its seed ...", device.Label()))
+	if err := device.Wipe(); err != nil {
+		return err
+	}
+	term.Result("Device wiped\n", nil)
+	return nil
+}
+
+func TrezorLock(term ui.Screen, flag *flags.Flags) error {
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	if err := device.Lock(); err != nil {
+		return err
+	}
+	term.Result("Device locked\n", nil)
+	return nil
+}
+
+func TrezorEndSession(term ui.Screen, flag *flags.Flags) error {
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	if err := device.EndSession(); err != nil {
+		return err
+	}
+	term.Result("Session ended\n", nil)
+	return nil
+}
+
+func TrezorPing(term ui.Screen, flag *flags.Flags) error {
+	device, err := firstTrezor(term)
+	if err != nil {
+		return err
+	}
+	msg, err := device.Ping(flag.FlagMessage, flag.FlagButton)
+	if err != nil {
+		return err
+	}
+	term.Result(fmt.Sprintf("%s\n", msg), &PingOutput{Message: msg})
+	return nil
+}
+
+// parsePassphraseFlag returns the passphrase protection of --passphrase on or
+// off, nil if not set
+func parsePassphraseFlag(flag *flags.Flags) (*bool, error) {
+	switch flag.FlagPassphrase {
+	case "":
+		return nil, nil
+	case "on", "off":
+		usePassphrase := flag.FlagPassphrase == "on"
+		return &usePassphrase, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("Invalid --passphrase: %s, use on or off", flag.FlagPassphrase))
+	}
+}
+
+func firstTrezor(term ui.Screen) (trezor.Device, error) {
+	devices, err := trezor.Devices(term)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, errors.New("No trezor devices found")
+	}
+	return devices[0], nil
+}
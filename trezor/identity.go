@@ -0,0 +1,77 @@
+package trezor
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/jaanek/jethwallet/accounts"
+	"github.com/jaanek/jethwallet/trezor/trezorproto"
+)
+
+// ParseIdentity parses an identity URI like ssh://user@host:22/path into the
+// identity of SignIdentity and GetECDHSessionKey. The index selects another
+// key of the same identity.
+func ParseIdentity(uri string, index uint32) (*trezorproto.IdentityType, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("trezor: invalid identity %q, use proto://user@host:port/path", uri)
+	}
+	identity := &trezorproto.IdentityType{
+		Proto: &u.Scheme,
+		Index: &index,
+	}
+	host := u.Hostname()
+	identity.Host = &host
+	if user := u.User.Username(); user != "" {
+		identity.User = &user
+	}
+	if port := u.Port(); port != "" {
+		identity.Port = &port
+	}
+	if u.Path != "" {
+		identity.Path = &u.Path
+	}
+	return identity, nil
+}
+
+// SignIdentity signs a login challenge with the key of identity. The visual
+// challenge is shown on device, the hidden one is only signed.
+// https://github.com/trezor/trezor-firmware/blob/master/python/src/trezorlib/misc.py#L40
+func (w *trezorWallet) SignIdentity(identity *trezorproto.IdentityType, challengeHidden []byte, challengeVisual string, curve string) (*trezorproto.SignedIdentity, error) {
+	if w.device == nil {
+		return nil, accounts.ErrWalletClosed
+	}
+	var request = &trezorproto.SignIdentity{
+		Identity:        identity,
+		ChallengeHidden: challengeHidden,
+		ChallengeVisual: &challengeVisual,
+		EcdsaCurveName:  &curve,
+	}
+	response := new(trezorproto.SignedIdentity)
+	if err := w.Call(request, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetECDHSessionKey derives a shared secret of the identity key and a peer
+// public key after the user has confirmed it on device
+// https://github.com/trezor/trezor-firmware/blob/master/python/src/trezorlib/misc.py#L56
+func (w *trezorWallet) GetECDHSessionKey(identity *trezorproto.IdentityType, peerPublicKey []byte, curve string) (*trezorproto.ECDHSessionKey, error) {
+	if w.device == nil {
+		return nil, accounts.ErrWalletClosed
+	}
+	var request = &trezorproto.GetECDHSessionKey{
+		Identity:       identity,
+		PeerPublicKey:  peerPublicKey,
+		EcdsaCurveName: &curve,
+	}
+	response := new(trezorproto.ECDHSessionKey)
+	if err := w.Call(request, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
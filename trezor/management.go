@@ -0,0 +1,111 @@
+package trezor
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/jaanek/jethwallet/accounts"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/trezor/trezorproto"
+	"github.com/jaanek/jethwallet/ui"
+)
+
+// Device is a Trezor wallet exposing the device management messages
+type Device interface {
+	hwcommon.HWWallet
+	Features() (*trezorproto.Features, error)
+	ApplySettings(settings *trezorproto.ApplySettings) error
+	ChangePin(remove bool) error
+	ChangeWipeCode(remove bool) error
+	Wipe() error
+	Lock() error
+	EndSession() error
+	Ping(msg string, button bool) (string, error)
+	ResetDevice(settings *trezorproto.ResetDevice) error
+	BackupDevice() error
+	RecoveryDevice(settings *trezorproto.RecoveryDevice) error
+	SignIdentity(identity *trezorproto.IdentityType, challengeHidden []byte, challengeVisual string, curve string) (*trezorproto.SignedIdentity, error)
+	GetECDHSessionKey(identity *trezorproto.IdentityType, peerPublicKey []byte, curve string) (*trezorproto.ECDHSessionKey, error)
+}
+
+// Devices returns the connected Trezor devices
+func Devices(term ui.Screen) ([]Device, error) {
+	wallets, err := Wallets(term)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]Device, 0, len(wallets))
+	for _, w := range wallets {
+		devices = append(devices, w.(*trezorWallet))
+	}
+	return devices, nil
+}
+
+// Features fetches the current device features
+func (w *trezorWallet) Features() (*trezorproto.Features, error) {
+	if w.device == nil {
+		return nil, accounts.ErrWalletClosed
+	}
+	features := new(trezorproto.Features)
+	if err := w.Call(&trezorproto.GetFeatures{}, features); err != nil {
+		return nil, err
+	}
+	w.features = features
+	return features, nil
+}
+
+// ApplySettings changes the device settings set in the request, like the
+// label and passphrase protection. The device asks for a confirmation.
+func (w *trezorWallet) ApplySettings(settings *trezorproto.ApplySettings) error {
+	return w.callSuccess(settings)
+}
+
+// ChangePin sets, changes or removes the PIN. The current and the new PIN are
+// asked through the PIN matrix.
+func (w *trezorWallet) ChangePin(remove bool) error {
+	return w.callSuccess(&trezorproto.ChangePin{Remove: &remove})
+}
+
+// ChangeWipeCode sets, changes or removes the wipe code
+func (w *trezorWallet) ChangeWipeCode(remove bool) error {
+	return w.callSuccess(&trezorproto.ChangeWipeCode{Remove: &remove})
+}
+
+// Wipe erases the seed and all settings from the device after it is
+// confirmed on the device
+func (w *trezorWallet) Wipe() error {
+	return w.callSuccess(&trezorproto.WipeDevice{})
+}
+
+// Lock locks the device, the PIN is asked again on next use
+func (w *trezorWallet) Lock() error {
+	return w.callSuccess(&trezorproto.LockDevice{})
+}
+
+// EndSession forgets the cached passphrase of the current session
+func (w *trezorWallet) EndSession() error {
+	return w.callSuccess(&trezorproto.EndSession{})
+}
+
+// Ping sends a message the device returns back, optionally after a button
+// confirmation
+func (w *trezorWallet) Ping(msg string, button bool) (string, error) {
+	if w.device == nil {
+		return "", accounts.ErrWalletClosed
+	}
+	success := new(trezorproto.Success)
+	if err := w.Call(&trezorproto.Ping{Message: &msg, ButtonProtection: &button}, success); err != nil {
+		return "", err
+	}
+	return success.GetMessage(), nil
+}
+
+func (w *trezorWallet) callSuccess(req proto.Message) error {
+	if w.device == nil {
+		return accounts.ErrWalletClosed
+	}
+	success := new(trezorproto.Success)
+	if err := w.Call(req, success); err != nil {
+		return err
+	}
+	w.ui.Logf("Trezor success: %s\n", success.GetMessage())
+	return nil
+}
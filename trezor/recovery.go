@@ -0,0 +1,113 @@
+package trezor
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jaanek/jethwallet/trezor/trezorproto"
+)
+
+const WORD_MATRIX = `
+Enter the position of the word letters shown on device matrix.
+Use the numeric keypad or lowercase letters, < deletes the previous letter.
+The layout is:
+    7 8 9        e r t
+    4 5 6  -or-  d f g
+    1 2 3        c v b
+The 6 letter matrix uses the left and right columns only.
+`
+
+// keypad letters of matrix positions, same layout as PIN_MATRIX
+var matrixLetters = map[rune]rune{
+	'e': '7', 'r': '8', 't': '9',
+	'd': '4', 'f': '5', 'g': '6',
+	'c': '1', 'v': '2', 'b': '3',
+}
+
+// ResetDevice initializes a new seed on the device. Host entropy is mixed in
+// through the entropy request and the device shows the words for backup
+// unless postponed with SkipBackup.
+// https://github.com/trezor/trezor-firmware/blob/master/python/src/trezorlib/device.py#L199
+func (w *trezorWallet) ResetDevice(settings *trezorproto.ResetDevice) error {
+	return w.callSuccess(settings)
+}
+
+// BackupDevice shows the seed words of a device initialized without backup
+func (w *trezorWallet) BackupDevice() error {
+	return w.callSuccess(&trezorproto.BackupDevice{})
+}
+
+// RecoveryDevice restores a seed from the words asked by the device. Trezor
+// One asks the words in scrambled order or as positions of a letter matrix,
+// newer models read the words on device.
+// https://github.com/trezor/trezor-firmware/blob/master/python/src/trezorlib/device.py#L148
+func (w *trezorWallet) RecoveryDevice(settings *trezorproto.RecoveryDevice) error {
+	if settings.GetType() == trezorproto.RecoveryDevice_RecoveryDeviceType_Matrix {
+		w.ui.Print(WORD_MATRIX)
+	}
+	return w.callSuccess(settings)
+}
+
+// entropyAck answers an entropy request with 32 bytes of host entropy
+func (w *trezorWallet) entropyAck() (trezorproto.MessageType, []byte, error) {
+	entropy := make([]byte, 32)
+	if _, err := rand.Read(entropy); err != nil {
+		w.rawCall(&trezorproto.Cancel{})
+		return 0, nil, err
+	}
+	return w.rawCall(&trezorproto.EntropyAck{Entropy: entropy})
+}
+
+// wordAck answers a word request of recovery with the word or matrix position
+// read from user
+func (w *trezorWallet) wordAck(reply []byte) (trezorproto.MessageType, []byte, error) {
+	request := new(trezorproto.WordRequest)
+	if err := proto.Unmarshal(reply, request); err != nil {
+		return 0, nil, err
+	}
+	var valid string
+	switch request.GetType() {
+	case trezorproto.WordRequest_WordRequestType_Matrix9:
+		w.ui.Print("*** NB! Enter the position of the letter on device matrix (not echoed)...")
+		valid = "123456789"
+	case trezorproto.WordRequest_WordRequestType_Matrix6:
+		w.ui.Print("*** NB! Enter the position of the last letter on device matrix (not echoed)...")
+		valid = "134679"
+	default:
+		w.ui.Print("*** NB! Enter the word asked on your Trezor screen (not echoed)...")
+	}
+	input, err := w.ui.ReadPassword()
+	if err != nil {
+		w.rawCall(&trezorproto.Cancel{})
+		return 0, nil, err
+	}
+	word := strings.ToLower(strings.TrimSpace(string(input)))
+	if valid != "" {
+		if word, err = matrixPosition(word, valid); err != nil {
+			w.rawCall(&trezorproto.Cancel{})
+			return 0, nil, err
+		}
+	}
+	return w.rawCall(&trezorproto.WordAck{Word: &word})
+}
+
+// matrixPosition converts a matrix input to the position digit or to the
+// backspace character
+func matrixPosition(input string, valid string) (string, error) {
+	if input == "<" {
+		return "\x08", nil
+	}
+	if len(input) != 1 {
+		return "", errors.New("trezor: Invalid matrix position provided")
+	}
+	pos := rune(input[0])
+	if digit, ok := matrixLetters[pos]; ok {
+		pos = digit
+	}
+	if !strings.ContainsRune(valid, pos) {
+		return "", errors.New("trezor: Invalid matrix position provided")
+	}
+	return string(pos), nil
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
 	"reflect"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/holiman/uint256"
 	"github.com/jaanek/jethwallet/accounts"
+	"github.com/jaanek/jethwallet/hdkey"
 	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
 	"github.com/jaanek/jethwallet/trezor/trezorproto"
 	"github.com/jaanek/jethwallet/ui"
@@ -131,57 +133,67 @@ func (w *trezorWallet) Label() string {
 	return w.features.GetLabel()
 }
 
+// DeviceID returns the unique id of the device or empty if unknown
+func (w *trezorWallet) DeviceID() string {
+	if w.features == nil || w.features.GetDeviceId() == "" {
+		return ""
+	}
+	return "trezor:" + w.features.GetDeviceId()
+}
+
 // https://github.com/trezor/trezor-firmware/blob/master/python/src/trezorlib/misc.py#L63
-func (w *trezorWallet) Encrypt(path accounts.DerivationPath, key string, data []byte, askOnEncrypt, askOnDecrypt bool) ([]byte, error) {
+func (w *trezorWallet) Encrypt(path accounts.DerivationPath, key string, data []byte, opts hwcommon.CipherOptions) ([]byte, error) {
 	if w.device == nil {
 		return nil, accounts.ErrWalletClosed
 	}
 	var err error
-	data, err = pkcs7pad(data, 16)
-	if err != nil {
-		return nil, err
-	}
-	var t bool = true
-	var request = &trezorproto.CipherKeyValue{
-		AddressN:     []uint32(path),
-		Key:          &key,
-		Value:        data,
-		Encrypt:      &t,
-		AskOnEncrypt: &askOnEncrypt,
-		AskOnDecrypt: &askOnDecrypt,
-		Iv:           []byte{},
-	}
-	response := new(trezorproto.CipheredKeyValue)
-	if err := w.Call(request, response); err != nil {
-		return nil, err
+	if opts.NoPadding {
+		if len(data)%16 != 0 {
+			return nil, errors.New("trezor: data length must be a multiple of 16 bytes without padding")
+		}
+	} else {
+		data, err = pkcs7pad(data, 16)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return response.Value, nil
+	return w.cipherKeyValue(path, key, data, true, opts)
 }
 
 // https://github.com/trezor/trezor-firmware/blob/master/python/src/trezorlib/misc.py#L87
-func (w *trezorWallet) Decrypt(path accounts.DerivationPath, key string, data []byte, askOnEncrypt, askOnDecrypt bool) ([]byte, error) {
+func (w *trezorWallet) Decrypt(path accounts.DerivationPath, key string, data []byte, opts hwcommon.CipherOptions) ([]byte, error) {
 	if w.device == nil {
 		return nil, accounts.ErrWalletClosed
 	}
-	var t bool = false
+	decrypted, err := w.cipherKeyValue(path, key, data, false, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.NoPadding {
+		return decrypted, nil
+	}
+	return pkcs7strip(decrypted, 16)
+}
+
+func (w *trezorWallet) cipherKeyValue(path accounts.DerivationPath, key string, data []byte, encrypt bool, opts hwcommon.CipherOptions) ([]byte, error) {
+	iv := opts.Iv
+	if iv == nil {
+		iv = []byte{}
+	}
 	var request = &trezorproto.CipherKeyValue{
 		AddressN:     []uint32(path),
 		Key:          &key,
 		Value:        data,
-		Encrypt:      &t,
-		AskOnEncrypt: &askOnEncrypt,
-		AskOnDecrypt: &askOnDecrypt,
-		Iv:           []byte{},
+		Encrypt:      &encrypt,
+		AskOnEncrypt: &opts.AskOnEncrypt,
+		AskOnDecrypt: &opts.AskOnDecrypt,
+		Iv:           iv,
 	}
 	response := new(trezorproto.CipheredKeyValue)
 	if err := w.Call(request, response); err != nil {
 		return nil, err
 	}
-	data, err := pkcs7strip(response.Value, 16)
-	if err != nil {
-		return nil, err
-	}
-	return data, nil
+	return response.Value, nil
 }
 
 // https://github.com/trezor/trezor-firmware/blob/master/python/src/trezorlib/ethereum.py#L127
@@ -200,13 +212,57 @@ func (w *trezorWallet) SignMessage(path accounts.DerivationPath, msg []byte) (co
 	return common.HexToAddress(*response.Address), response.Signature, nil
 }
 
+// VerifyMessage verifies a personal message signature on the device. Trezor
+// adds the Ethereum signature prefix and shows the signer and message on its
+// screen. Returns an error if the signature is not valid for the address.
+func (w *trezorWallet) VerifyMessage(address common.Address, signature []byte, msg []byte) error {
+	if w.device == nil {
+		return accounts.ErrWalletClosed
+	}
+	addr := address.Hex()
+	var request = &trezorproto.EthereumVerifyMessage{
+		Address:   &addr,
+		Signature: signature,
+		Message:   msg,
+	}
+	response := new(trezorproto.Success)
+	if err := w.Call(request, response); err != nil {
+		return err
+	}
+	w.ui.Logf("Trezor verify message: %s\n", response.GetMessage())
+	return nil
+}
+
+// SignTypedMessage signs EIP-712 typed data given as domain separator and
+// message hashes. Only Trezor One accepts hash based signing, Trezor T
+// answers with a failure.
+// https://github.com/trezor/trezor-firmware/blob/master/python/src/trezorlib/ethereum.py#L232
+func (w *trezorWallet) SignTypedMessage(path accounts.DerivationPath, domainHash []byte, messageHash []byte) (common.Address, []byte, error) {
+	if w.device == nil {
+		return common.Address{}, nil, accounts.ErrWalletClosed
+	}
+	var request = &trezorproto.EthereumSignTypedHash{
+		AddressN:            []uint32(path),
+		DomainSeparatorHash: domainHash,
+		MessageHash:         messageHash,
+	}
+	response := new(trezorproto.EthereumTypedDataSignature)
+	if err := w.Call(request, response); err != nil {
+		return common.Address{}, nil, err
+	}
+	return common.HexToAddress(response.GetAddress()), response.GetSignature(), nil
+}
+
 // SignTx sends the transaction to the Trezor and
 // waits for the user to confirm or deny the transaction.
 func (w *trezorWallet) SignTx(path accounts.DerivationPath, tx types.Transaction, chainID *uint256.Int) (common.Address, types.Transaction, error) {
 	if w.device == nil {
 		return common.Address{}, nil, accounts.ErrWalletClosed
 	}
-	chainId := uint32(chainID.ToBig().Int64()) // EIP-155 transaction, set chain ID explicitly (only 32 bit is supported!?)
+	chainId, err := w.signChainID(chainID) // EIP-155 transaction, set chain ID explicitly
+	if err != nil {
+		return common.Address{}, nil, err
+	}
 	var toAddr *string
 	if to := tx.GetTo(); to != nil {
 		// Non contract deploy, set recipient explicitly
@@ -227,7 +283,10 @@ func (w *trezorWallet) SignTx(path accounts.DerivationPath, tx types.Transaction
 	// build trezor tx
 	var req proto.Message
 	switch tx.Type() {
-	case types.LegacyTxType, types.AccessListTxType:
+	case types.AccessListTxType:
+		// Trezor firmware signs access lists in EIP-1559 txs only
+		return common.Address{}, nil, errors.New("trezor: access list (type 1) txs are not supported, use a dynamic fee (type 2) tx with the access list")
+	case types.LegacyTxType:
 		var request = &trezorproto.EthereumSignTx{
 			AddressN:         []uint32(path),
 			To:               toAddr,
@@ -252,6 +311,7 @@ func (w *trezorWallet) SignTx(path accounts.DerivationPath, tx types.Transaction
 			DataInitialChunk: dataInitialChunk,
 			DataLength:       &length,
 			ChainId:          &chainId,
+			AccessList:       trezorAccessList(tx.GetAccessList()),
 		}
 		req = request
 	default:
@@ -261,6 +321,73 @@ func (w *trezorWallet) SignTx(path accounts.DerivationPath, tx types.Transaction
 	return w.sendTx(req, tx, chainID, data)
 }
 
+// Firmware versions sending the chain id as uint64. Older ones only accept a
+// uint32 chain id.
+var (
+	chainID64MinVersion1 = [3]uint32{1, 10, 4}
+	chainID64MinVersion2 = [3]uint32{2, 4, 3}
+)
+
+// maxLegacyChainID is the largest chain id for which the v of a legacy tx
+// fits in uint32. Above it the device returns only the recovery id as v.
+const maxLegacyChainID = (math.MaxUint32 - 36) / 2
+
+// signChainID validates the chain id against the limit of the firmware
+// instead of truncating it
+func (w *trezorWallet) signChainID(chainID *uint256.Int) (uint64, error) {
+	if !chainID.IsUint64() {
+		return 0, fmt.Errorf("trezor: chain id %s does not fit into 64 bits", chainID)
+	}
+	id := chainID.Uint64()
+	if id > math.MaxUint32 && !w.supportsChainID64() {
+		min := w.chainID64MinVersion()
+		return 0, fmt.Errorf("trezor: chain id %d does not fit into 32 bits, update the firmware v%s to v%d.%d.%d or newer for 64 bit chain ids", id, w.Version(), min[0], min[1], min[2])
+	}
+	return id, nil
+}
+
+// supportsChainID64 reports whether the firmware accepts a 64 bit chain id
+func (w *trezorWallet) supportsChainID64() bool {
+	if w.features == nil {
+		return false
+	}
+	version := [3]uint32{w.features.GetMajorVersion(), w.features.GetMinorVersion(), w.features.GetPatchVersion()}
+	min := w.chainID64MinVersion()
+	for i := range version {
+		if version[i] != min[i] {
+			return version[i] > min[i]
+		}
+	}
+	return true
+}
+
+func (w *trezorWallet) chainID64MinVersion() [3]uint32 {
+	if w.features.GetMajorVersion() == 1 {
+		return chainID64MinVersion1
+	}
+	return chainID64MinVersion2
+}
+
+// trezorAccessList converts an EIP-2930 access list to the EIP-1559 sign request
+func trezorAccessList(accessList types.AccessList) []*trezorproto.EthereumSignTxEIP1559_EthereumAccessList {
+	if len(accessList) == 0 {
+		return nil
+	}
+	result := make([]*trezorproto.EthereumSignTxEIP1559_EthereumAccessList, 0, len(accessList))
+	for _, tuple := range accessList {
+		address := tuple.Address.Hex()
+		keys := make([][]byte, 0, len(tuple.StorageKeys))
+		for _, key := range tuple.StorageKeys {
+			keys = append(keys, common.CopyBytes(key[:]))
+		}
+		result = append(result, &trezorproto.EthereumSignTxEIP1559_EthereumAccessList{
+			Address:     &address,
+			StorageKeys: keys,
+		})
+	}
+	return result
+}
+
 func (w *trezorWallet) sendTx(req proto.Message, tx types.Transaction, chainID *uint256.Int, data []byte) (common.Address, types.Transaction, error) {
 	response := new(trezorproto.EthereumTxRequest)
 	if err := w.Call(req, response); err != nil {
@@ -283,7 +410,7 @@ func (w *trezorWallet) sendTx(req proto.Message, tx types.Transaction, chainID *
 
 	// Create the correct signer and signature transform based on the chain ID
 	signer := types.LatestSignerForChainID(chainID.ToBig())
-	if tx.Type() == types.LegacyTxType {
+	if tx.Type() == types.LegacyTxType && chainID.Uint64() <= maxLegacyChainID {
 		signature[64] -= byte(chainID.Uint64()*2 + 35)
 	}
 
@@ -301,8 +428,18 @@ func (w *trezorWallet) sendTx(req proto.Message, tx types.Transaction, chainID *
 }
 
 func (w *trezorWallet) Derive(path accounts.DerivationPath) (common.Address, error) {
+	return w.getAddress(path, false)
+}
+
+// ShowAddress displays the address of path on the device and returns it after
+// the user has confirmed it.
+func (w *trezorWallet) ShowAddress(path accounts.DerivationPath) (common.Address, error) {
+	return w.getAddress(path, true)
+}
+
+func (w *trezorWallet) getAddress(path accounts.DerivationPath, showDisplay bool) (common.Address, error) {
 	address := new(trezorproto.EthereumAddress)
-	if err := w.Call(&trezorproto.EthereumGetAddress{AddressN: []uint32(path)}, address); err != nil {
+	if err := w.Call(&trezorproto.EthereumGetAddress{AddressN: []uint32(path), ShowDisplay: &showDisplay}, address); err != nil {
 		return common.Address{}, err
 	}
 	if addr := address.GetXOldAddress(); len(addr) > 0 { // Older firmwares use binary formats
@@ -314,6 +451,44 @@ func (w *trezorWallet) Derive(path accounts.DerivationPath) (common.Address, err
 	return common.Address{}, errors.New("missing derived address")
 }
 
+// ExtendedPublicKey returns the BIP32 extended public key of path, used to
+// derive the child addresses offline
+func (w *trezorWallet) ExtendedPublicKey(path accounts.DerivationPath) (*hdkey.ExtendedKey, error) {
+	pubkey := new(trezorproto.EthereumPublicKey)
+	if err := w.Call(&trezorproto.EthereumGetPublicKey{AddressN: []uint32(path)}, pubkey); err != nil {
+		return nil, err
+	}
+	node := pubkey.GetNode()
+	if node == nil {
+		return nil, errors.New("missing public key node")
+	}
+	key, err := hdkey.NewExtendedKey(node.GetPublicKey(), node.GetChainCode(), uint8(node.GetDepth()), node.GetFingerprint(), node.GetChildNum())
+	if err != nil {
+		return nil, err
+	}
+	if xpub := pubkey.GetXpub(); xpub != "" && xpub != key.String() {
+		return nil, fmt.Errorf("trezor: xpub %s does not match public node", xpub)
+	}
+	return key, nil
+}
+
+// pinPrompt returns the PIN prompt of a PIN matrix request type, used when the
+// PIN or wipe code is changed
+func pinPrompt(kind trezorproto.PinMatrixRequest_PinMatrixRequestType) string {
+	switch kind {
+	case trezorproto.PinMatrixRequest_PinMatrixRequestType_NewFirst:
+		return "*** NB! Enter new PIN (not echoed)..."
+	case trezorproto.PinMatrixRequest_PinMatrixRequestType_NewSecond:
+		return "*** NB! Enter new PIN again (not echoed)..."
+	case trezorproto.PinMatrixRequest_PinMatrixRequestType_WipeCodeFirst:
+		return "*** NB! Enter new wipe code (not echoed)..."
+	case trezorproto.PinMatrixRequest_PinMatrixRequestType_WipeCodeSecond:
+		return "*** NB! Enter new wipe code again (not echoed)..."
+	default:
+		return "*** NB! Enter PIN (not echoed)..."
+	}
+}
+
 // https://github.com/trezor/trezor-firmware/blob/master/python/src/trezorlib/client.py#L216
 func (w *trezorWallet) Call(req proto.Message, result proto.Message) error {
 	kind, reply, err := w.rawCall(req)
@@ -325,7 +500,11 @@ func (w *trezorWallet) Call(req proto.Message, result proto.Message) error {
 		switch kind {
 		case trezorproto.MessageType_MessageType_PinMatrixRequest:
 			{
-				w.ui.Print("*** NB! Enter PIN (not echoed)...")
+				request := new(trezorproto.PinMatrixRequest)
+				if err := proto.Unmarshal(reply, request); err != nil {
+					return err
+				}
+				w.ui.Print(pinPrompt(request.GetType()))
 				w.ui.Print(PIN_MATRIX)
 				pin, err := w.ui.ReadPassword()
 				if err != nil {
@@ -373,6 +552,24 @@ func (w *trezorWallet) Call(req proto.Message, result proto.Message) error {
 				}
 				w.ui.Logf("Trezor button success. kind: %s\n", MessageName(kind))
 			}
+		case trezorproto.MessageType_MessageType_EntropyRequest:
+			{
+				// Trezor is creating a new seed, mix in host entropy
+				kind, reply, err = w.entropyAck()
+				if err != nil {
+					return err
+				}
+				w.ui.Logf("Trezor entropy success. kind: %s\n", MessageName(kind))
+			}
+		case trezorproto.MessageType_MessageType_WordRequest:
+			{
+				// Trezor is recovering a seed, send the asked word
+				kind, reply, err = w.wordAck(reply)
+				if err != nil {
+					return err
+				}
+				w.ui.Logf("Trezor word success. kind: %s\n", MessageName(kind))
+			}
 		case trezorproto.MessageType_MessageType_Failure:
 			{
 				// Trezor returned a failure, extract and return the message
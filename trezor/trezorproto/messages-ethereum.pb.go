@@ -278,7 +278,7 @@ type EthereumSignTx struct {
 	Value            []byte   `protobuf:"bytes,6,opt,name=value" json:"value,omitempty"`                                                 // <=256 bit unsigned big endian (in wei)
 	DataInitialChunk []byte   `protobuf:"bytes,7,opt,name=data_initial_chunk,json=dataInitialChunk" json:"data_initial_chunk,omitempty"` // The initial data chunk (<= 1024 bytes)
 	DataLength       *uint32  `protobuf:"varint,8,opt,name=data_length,json=dataLength" json:"data_length,omitempty"`                    // Length of transaction payload
-	ChainId          *uint32  `protobuf:"varint,9,opt,name=chain_id,json=chainId" json:"chain_id,omitempty"`                             // Chain Id for EIP 155
+	ChainId          *uint64  `protobuf:"varint,9,opt,name=chain_id,json=chainId" json:"chain_id,omitempty"`                             // Chain Id for EIP 155
 	TxType           *uint32  `protobuf:"varint,10,opt,name=tx_type,json=txType" json:"tx_type,omitempty"`                               // Used for Wanchain
 }
 
@@ -370,7 +370,7 @@ func (x *EthereumSignTx) GetDataLength() uint32 {
 	return 0
 }
 
-func (x *EthereumSignTx) GetChainId() uint32 {
+func (x *EthereumSignTx) GetChainId() uint64 {
 	if x != nil && x.ChainId != nil {
 		return *x.ChainId
 	}
@@ -404,7 +404,7 @@ type EthereumSignTxEIP1559 struct {
 	Value            []byte                                      `protobuf:"bytes,7,req,name=value" json:"value,omitempty"`                                                      // <=256 bit unsigned big endian (in wei)
 	DataInitialChunk []byte                                      `protobuf:"bytes,8,opt,name=data_initial_chunk,json=dataInitialChunk,def=" json:"data_initial_chunk,omitempty"` // The initial data chunk (<= 1024 bytes)
 	DataLength       *uint32                                     `protobuf:"varint,9,req,name=data_length,json=dataLength" json:"data_length,omitempty"`                         // Length of transaction payload
-	ChainId          *uint32                                     `protobuf:"varint,10,req,name=chain_id,json=chainId" json:"chain_id,omitempty"`                                 // Chain Id for EIP 155
+	ChainId          *uint64                                     `protobuf:"varint,10,req,name=chain_id,json=chainId" json:"chain_id,omitempty"`                                 // Chain Id for EIP 155
 	AccessList       []*EthereumSignTxEIP1559_EthereumAccessList `protobuf:"bytes,11,rep,name=access_list,json=accessList" json:"access_list,omitempty"`                         // Access List
 }
 
@@ -513,7 +513,7 @@ func (x *EthereumSignTxEIP1559) GetDataLength() uint32 {
 	return 0
 }
 
-func (x *EthereumSignTxEIP1559) GetChainId() uint32 {
+func (x *EthereumSignTxEIP1559) GetChainId() uint64 {
 	if x != nil && x.ChainId != nil {
 		return *x.ChainId
 	}
@@ -840,6 +840,132 @@ func (x *EthereumVerifyMessage) GetAddress() string {
 	return ""
 }
 
+//*
+// Request: Ask device to sign hash of typed data
+// @start
+// @next EthereumTypedDataSignature
+// @next Failure
+type EthereumSignTypedHash struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AddressN            []uint32 `protobuf:"varint,1,rep,name=address_n,json=addressN" json:"address_n,omitempty"`                                   // BIP-32 path to derive the key from master node
+	DomainSeparatorHash []byte   `protobuf:"bytes,2,req,name=domain_separator_hash,json=domainSeparatorHash" json:"domain_separator_hash,omitempty"` // Hash of domainSeparator of typed data to be signed
+	MessageHash         []byte   `protobuf:"bytes,3,opt,name=message_hash,json=messageHash" json:"message_hash,omitempty"`                           // Hash of the data of typed data to be signed (empty if domain-only data)
+}
+
+func (x *EthereumSignTypedHash) Reset() {
+	*x = EthereumSignTypedHash{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_ethereum_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EthereumSignTypedHash) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EthereumSignTypedHash) ProtoMessage() {}
+
+func (x *EthereumSignTypedHash) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_ethereum_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EthereumSignTypedHash.ProtoReflect.Descriptor instead.
+func (*EthereumSignTypedHash) Descriptor() ([]byte, []int) {
+	return file_messages_ethereum_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EthereumSignTypedHash) GetAddressN() []uint32 {
+	if x != nil {
+		return x.AddressN
+	}
+	return nil
+}
+
+func (x *EthereumSignTypedHash) GetDomainSeparatorHash() []byte {
+	if x != nil {
+		return x.DomainSeparatorHash
+	}
+	return nil
+}
+
+func (x *EthereumSignTypedHash) GetMessageHash() []byte {
+	if x != nil {
+		return x.MessageHash
+	}
+	return nil
+}
+
+//*
+// Response: Signed typed data
+// @end
+type EthereumTypedDataSignature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Signature []byte  `protobuf:"bytes,1,req,name=signature" json:"signature,omitempty"` // signature of the typed data
+	Address   *string `protobuf:"bytes,2,req,name=address" json:"address,omitempty"`     // address used to sign the typed data
+}
+
+func (x *EthereumTypedDataSignature) Reset() {
+	*x = EthereumTypedDataSignature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_ethereum_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EthereumTypedDataSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EthereumTypedDataSignature) ProtoMessage() {}
+
+func (x *EthereumTypedDataSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_ethereum_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EthereumTypedDataSignature.ProtoReflect.Descriptor instead.
+func (*EthereumTypedDataSignature) Descriptor() ([]byte, []int) {
+	return file_messages_ethereum_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *EthereumTypedDataSignature) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *EthereumTypedDataSignature) GetAddress() string {
+	if x != nil && x.Address != nil {
+		return *x.Address
+	}
+	return ""
+}
+
 type EthereumSignTxEIP1559_EthereumAccessList struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -852,7 +978,7 @@ type EthereumSignTxEIP1559_EthereumAccessList struct {
 func (x *EthereumSignTxEIP1559_EthereumAccessList) Reset() {
 	*x = EthereumSignTxEIP1559_EthereumAccessList{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_messages_ethereum_proto_msgTypes[11]
+		mi := &file_messages_ethereum_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -865,7 +991,7 @@ func (x *EthereumSignTxEIP1559_EthereumAccessList) String() string {
 func (*EthereumSignTxEIP1559_EthereumAccessList) ProtoMessage() {}
 
 func (x *EthereumSignTxEIP1559_EthereumAccessList) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_ethereum_proto_msgTypes[11]
+	mi := &file_messages_ethereum_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -941,7 +1067,7 @@ var file_messages_ethereum_proto_rawDesc = []byte{
 	0x6c, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x6c,
 	0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x64, 0x61, 0x74,
 	0x61, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x68, 0x61, 0x69, 0x6e,
-	0x5f, 0x69, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x63, 0x68, 0x61, 0x69, 0x6e,
+	0x5f, 0x69, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x63, 0x68, 0x61, 0x69, 0x6e,
 	0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x78, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x0a, 0x20,
 	0x01, 0x28, 0x0d, 0x52, 0x06, 0x74, 0x78, 0x54, 0x79, 0x70, 0x65, 0x22, 0x80, 0x04, 0x0a, 0x15,
 	0x45, 0x74, 0x68, 0x65, 0x72, 0x65, 0x75, 0x6d, 0x53, 0x69, 0x67, 0x6e, 0x54, 0x78, 0x45, 0x49,
@@ -963,7 +1089,7 @@ var file_messages_ethereum_proto_rawDesc = []byte{
 	0x61, 0x6c, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x61, 0x74, 0x61, 0x5f,
 	0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x09, 0x20, 0x02, 0x28, 0x0d, 0x52, 0x0a, 0x64, 0x61,
 	0x74, 0x61, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x68, 0x61, 0x69,
-	0x6e, 0x5f, 0x69, 0x64, 0x18, 0x0a, 0x20, 0x02, 0x28, 0x0d, 0x52, 0x07, 0x63, 0x68, 0x61, 0x69,
+	0x6e, 0x5f, 0x69, 0x64, 0x18, 0x0a, 0x20, 0x02, 0x28, 0x04, 0x52, 0x07, 0x63, 0x68, 0x61, 0x69,
 	0x6e, 0x49, 0x64, 0x12, 0x66, 0x0a, 0x0b, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x6c, 0x69,
 	0x73, 0x74, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x45, 0x2e, 0x68, 0x77, 0x2e, 0x74, 0x72,
 	0x65, 0x7a, 0x6f, 0x72, 0x2e, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x65, 0x74,
@@ -1005,12 +1131,26 @@ var file_messages_ethereum_proto_rawDesc = []byte{
 	0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01,
 	0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x61,
 	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64,
-	0x64, 0x72, 0x65, 0x73, 0x73, 0x42, 0x4b, 0x0a, 0x23, 0x63, 0x6f, 0x6d, 0x2e, 0x73, 0x61, 0x74,
-	0x6f, 0x73, 0x68, 0x69, 0x6c, 0x61, 0x62, 0x73, 0x2e, 0x74, 0x72, 0x65, 0x7a, 0x6f, 0x72, 0x2e,
-	0x6c, 0x69, 0x62, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x42, 0x15, 0x54, 0x72,
-	0x65, 0x7a, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x45, 0x74, 0x68, 0x65, 0x72,
-	0x65, 0x75, 0x6d, 0x5a, 0x0d, 0x2e, 0x2f, 0x74, 0x72, 0x65, 0x7a, 0x6f, 0x72, 0x70, 0x72, 0x6f,
-	0x74, 0x6f,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x8b, 0x01, 0x0a, 0x15, 0x45, 0x74, 0x68, 0x65, 0x72, 0x65,
+	0x75, 0x6d, 0x53, 0x69, 0x67, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x64, 0x48, 0x61, 0x73, 0x68, 0x12,
+	0x1b, 0x0a, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x5f, 0x6e, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0d, 0x52, 0x08, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x4e, 0x12, 0x32, 0x0a, 0x15,
+	0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x5f, 0x73, 0x65, 0x70, 0x61, 0x72, 0x61, 0x74, 0x6f, 0x72,
+	0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x02, 0x28, 0x0c, 0x52, 0x13, 0x64, 0x6f, 0x6d,
+	0x61, 0x69, 0x6e, 0x53, 0x65, 0x70, 0x61, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x48, 0x61, 0x73, 0x68,
+	0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x68, 0x61, 0x73, 0x68,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48,
+	0x61, 0x73, 0x68, 0x22, 0x54, 0x0a, 0x1a, 0x45, 0x74, 0x68, 0x65, 0x72, 0x65, 0x75, 0x6d, 0x54,
+	0x79, 0x70, 0x65, 0x64, 0x44, 0x61, 0x74, 0x61, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x01,
+	0x20, 0x02, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x02, 0x28, 0x09,
+	0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x42, 0x4b, 0x0a, 0x23, 0x63, 0x6f, 0x6d,
+	0x2e, 0x73, 0x61, 0x74, 0x6f, 0x73, 0x68, 0x69, 0x6c, 0x61, 0x62, 0x73, 0x2e, 0x74, 0x72, 0x65,
+	0x7a, 0x6f, 0x72, 0x2e, 0x6c, 0x69, 0x62, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x42, 0x15, 0x54, 0x72, 0x65, 0x7a, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x45,
+	0x74, 0x68, 0x65, 0x72, 0x65, 0x75, 0x6d, 0x5a, 0x0d, 0x2e, 0x2f, 0x74, 0x72, 0x65, 0x7a, 0x6f,
+	0x72, 0x70, 0x72, 0x6f, 0x74, 0x6f,
 }
 
 var (
@@ -1025,7 +1165,7 @@ func file_messages_ethereum_proto_rawDescGZIP() []byte {
 	return file_messages_ethereum_proto_rawDescData
 }
 
-var file_messages_ethereum_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_messages_ethereum_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_messages_ethereum_proto_goTypes = []interface{}{
 	(*EthereumGetPublicKey)(nil),                     // 0: hw.trezor.messages.ethereum.EthereumGetPublicKey
 	(*EthereumPublicKey)(nil),                        // 1: hw.trezor.messages.ethereum.EthereumPublicKey
@@ -1038,12 +1178,14 @@ var file_messages_ethereum_proto_goTypes = []interface{}{
 	(*EthereumSignMessage)(nil),                      // 8: hw.trezor.messages.ethereum.EthereumSignMessage
 	(*EthereumMessageSignature)(nil),                 // 9: hw.trezor.messages.ethereum.EthereumMessageSignature
 	(*EthereumVerifyMessage)(nil),                    // 10: hw.trezor.messages.ethereum.EthereumVerifyMessage
-	(*EthereumSignTxEIP1559_EthereumAccessList)(nil), // 11: hw.trezor.messages.ethereum.EthereumSignTxEIP1559.EthereumAccessList
-	(*HDNodeType)(nil),                               // 12: hw.trezor.messages.common.HDNodeType
+	(*EthereumSignTypedHash)(nil),                    // 11: hw.trezor.messages.ethereum.EthereumSignTypedHash
+	(*EthereumTypedDataSignature)(nil),               // 12: hw.trezor.messages.ethereum.EthereumTypedDataSignature
+	(*EthereumSignTxEIP1559_EthereumAccessList)(nil), // 13: hw.trezor.messages.ethereum.EthereumSignTxEIP1559.EthereumAccessList
+	(*HDNodeType)(nil),                               // 14: hw.trezor.messages.common.HDNodeType
 }
 var file_messages_ethereum_proto_depIdxs = []int32{
-	12, // 0: hw.trezor.messages.ethereum.EthereumPublicKey.node:type_name -> hw.trezor.messages.common.HDNodeType
-	11, // 1: hw.trezor.messages.ethereum.EthereumSignTxEIP1559.access_list:type_name -> hw.trezor.messages.ethereum.EthereumSignTxEIP1559.EthereumAccessList
+	14, // 0: hw.trezor.messages.ethereum.EthereumPublicKey.node:type_name -> hw.trezor.messages.common.HDNodeType
+	13, // 1: hw.trezor.messages.ethereum.EthereumSignTxEIP1559.access_list:type_name -> hw.trezor.messages.ethereum.EthereumSignTxEIP1559.EthereumAccessList
 	2,  // [2:2] is the sub-list for method output_type
 	2,  // [2:2] is the sub-list for method input_type
 	2,  // [2:2] is the sub-list for extension type_name
@@ -1191,6 +1333,30 @@ func file_messages_ethereum_proto_init() {
 			}
 		}
 		file_messages_ethereum_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EthereumSignTypedHash); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_ethereum_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EthereumTypedDataSignature); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_ethereum_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*EthereumSignTxEIP1559_EthereumAccessList); i {
 			case 0:
 				return &v.state
@@ -1209,7 +1375,7 @@ func file_messages_ethereum_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_messages_ethereum_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   12,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// JSONTerminal is a screen for machine-readable output. Command results are
+// collected and written to stdout as a single JSON object by Finish.
+type JSONTerminal struct {
+	Screen
+	results []interface{}
+	list    bool
+}
+
+type jsonOutput struct {
+	Ok     bool        `json:"ok"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+func NewJSONTerminal(verbose bool) *JSONTerminal {
+	return &JSONTerminal{Screen: NewTerminal(verbose)}
+}
+
+// Output collects plain text output as a result
+func (t *JSONTerminal) Output(msg string) {
+	t.results = append(t.results, msg)
+}
+
+// Result collects the result object. Text is used if there is no object.
+func (t *JSONTerminal) Result(text string, result interface{}) {
+	if result == nil {
+		t.Output(text)
+		return
+	}
+	t.results = append(t.results, result)
+}
+
+// ListResults makes Finish write the result as an array, whatever the number
+// of results
+func (t *JSONTerminal) ListResults() {
+	t.list = true
+}
+
+// Finish writes the collected results or the error of a command
+func (t *JSONTerminal) Finish(err error) {
+	out := jsonOutput{Ok: err == nil}
+	if err != nil {
+		out.Error = err.Error()
+	}
+	switch {
+	case t.list:
+		// an empty list too
+		out.Result = append([]interface{}{}, t.results...)
+	case len(t.results) == 1:
+		out.Result = t.results[0]
+	case len(t.results) > 1:
+		out.Result = t.results
+	}
+	outb, merr := json.Marshal(&out)
+	if merr != nil {
+		outb, _ = json.Marshal(&jsonOutput{Error: merr.Error()})
+	}
+	fmt.Fprintf(os.Stdout, "%s\n", outb)
+}
@@ -13,6 +13,8 @@ type Screen interface {
 	ReadPassword() ([]byte, error)
 	Print(msg string)
 	Output(msg string)
+	Result(text string, result interface{})
+	ListResults()
 	Log(msg interface{})
 	Logf(msg string, args ...interface{})
 	Error(msg interface{})
@@ -40,6 +42,17 @@ func (t *term) Output(msg string) {
 	fmt.Fprint(os.Stdout, msg)
 }
 
+// Result outputs a command result. The text is printed as is and the result
+// object is used in JSON mode only.
+func (t *term) Result(text string, result interface{}) {
+	if text != "" {
+		t.Output(text)
+	}
+}
+
+// ListResults marks the results as a list. Used in JSON mode only.
+func (t *term) ListResults() {}
+
 func (t *term) Logf(msg string, args ...interface{}) {
 	if t.verbose {
 		fmt.Fprintf(os.Stderr, fmt.Sprintf(msg, args...))
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jaanek/jethwallet/accounts"
+	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/hwwallet"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+// VerifyAddress asks the hw wallet to display the address so the user can
+// compare it with the printed one
+func VerifyAddress(term ui.Screen, flag *flags.Flags) error {
+	if flag.FlagFrom == "" && flag.Hdpath == "" {
+		return errors.New("Missing --from or --hd")
+	}
+	if flag.KeystorePath != "" {
+		return errors.New("Address verification needs a hw wallet (--trezor or --ledger)")
+	}
+	var fromAddr common.Address
+	if flag.FlagFrom != "" {
+		if !common.IsHexAddress(flag.FlagFrom) {
+			return errors.New(fmt.Sprintf("Invalid --from address: %s", flag.FlagFrom))
+		}
+		fromAddr = common.HexToAddress(flag.FlagFrom)
+	}
+	walletType := hwcommon.GetWalletTypeFromFlags(flag)
+	acc, err := hwwallet.VerifyAddress(term, walletType, fromAddr, flag.Hdpath, flag.Max)
+	if err != nil {
+		return err
+	}
+	term.Result(fmt.Sprintf("%s hd-path-%s verified\n", acc.Address.Hex(), acc.URL.Path), &accounts.AccountOutput{Address: acc.Address.Hex(), Path: acc.URL.Path})
+	return nil
+}
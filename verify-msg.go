@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jaanek/jethwallet/accounts"
+	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/hwwallet"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/ui"
+	"github.com/jaanek/jethwallet/wallet"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+)
+
+type VerifyOutput struct {
+	Address string `json:"address"`
+	Valid   bool   `json:"valid"`
+}
+
+// VerifyMsg verifies a personal message (with Ethereum signature prefix)
+// signature of an address. With --trezor the signature is verified on the
+// device and the signer and message are shown on its screen, otherwise it is
+// verified in software without a wallet.
+func VerifyMsg(term ui.Screen, flag *flags.Flags) error {
+	if flag.UseLedger {
+		return fmt.Errorf("Ledger can not verify message signatures: %w", accounts.ErrNotSupported)
+	}
+	if flag.FlagFrom == "" {
+		return errors.New("Missing --from address")
+	}
+	if flag.FlagInput == "" {
+		return errors.New("Missing --data")
+	}
+	if flag.FlagSignature == "" {
+		return errors.New("Missing --sig")
+	}
+	if !common.IsHexAddress(flag.FlagFrom) {
+		return errors.New(fmt.Sprintf("Invalid --from address: %s", flag.FlagFrom))
+	}
+	address := common.HexToAddress(flag.FlagFrom)
+	signature, err := hexutil.Decode(flag.FlagSignature)
+	if err != nil {
+		return fmt.Errorf("Invalid --sig: %w", err)
+	}
+	data := []byte(flag.FlagInput)
+	if strings.HasPrefix(flag.FlagInput, "0x") {
+		if data, err = hexutil.Decode(flag.FlagInput); err != nil {
+			return fmt.Errorf("Invalid --data: %w", err)
+		}
+	}
+	if len(signature) == 65 && signature[64] < 27 {
+		signature[64] += 27
+	}
+
+	if flag.UseTrezor {
+		err = hwwallet.VerifyMsg(term, hwcommon.Trezor, address, signature, data)
+	} else {
+		err = verifyMsgSignature(address, signature, data)
+	}
+	if err != nil {
+		return fmt.Errorf("Signature verification failed: %w", err)
+	}
+	term.Result(fmt.Sprintf("Valid signature of %s\n", address.Hex()), &VerifyOutput{Address: address.Hex(), Valid: true})
+	return nil
+}
+
+func verifyMsgSignature(address common.Address, signature []byte, data []byte) error {
+	// EcRecover modifies the V byte of signature
+	signer, err := wallet.EcRecover(wallet.MessageWithEthPrefix(data), common.CopyBytes(signature))
+	if err != nil {
+		return err
+	}
+	if signer != address {
+		return errors.New(fmt.Sprintf("signed by %s", signer.Hex()))
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/common/math"
+)
+
+var bigIntType = reflect.TypeOf(&big.Int{})
+
+// ParseMethodSig parses a human-readable method signature like
+// "transfer(address,uint256)" or "submit((address to,uint256 value)[] calls)".
+// Argument names are optional, tuples are written in parentheses.
+func ParseMethodSig(sig string) (abi.Method, error) {
+	sig = strings.TrimSpace(sig)
+	open := strings.Index(sig, "(")
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return abi.Method{}, fmt.Errorf("invalid method signature: %s", sig)
+	}
+	name := strings.TrimSpace(sig[:open])
+	params, err := splitTopLevel(sig[open+1 : len(sig)-1])
+	if err != nil {
+		return abi.Method{}, fmt.Errorf("invalid method signature: %s. Error: %w", sig, err)
+	}
+	inputs := make(abi.Arguments, 0, len(params))
+	for i, param := range params {
+		marshaling, err := parseArgMarshaling(param, fmt.Sprintf("arg%d", i))
+		if err != nil {
+			return abi.Method{}, err
+		}
+		argType, err := abi.NewType(marshaling.Type, "", marshaling.Components)
+		if err != nil {
+			return abi.Method{}, fmt.Errorf("argument contains invalid type: %s. Error: %w", param, err)
+		}
+		inputs = append(inputs, abi.Argument{Name: marshaling.Name, Type: argType})
+	}
+	return abi.NewMethod(name, name, abi.Function, "", false, false, inputs, nil), nil
+}
+
+// LoadABI reads a contract ABI json file
+func LoadABI(path string) (abi.ABI, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return abi.ABI{}, err
+	}
+	defer f.Close()
+	contractAbi, err := abi.JSON(f)
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("invalid abi file %s: %w", path, err)
+	}
+	return contractAbi, nil
+}
+
+// FindMethod looks up a method from contract ABI by its name (as "transfer")
+// or full signature (as "transfer(address,uint256)"). Overloaded methods must
+// be selected by signature.
+func FindMethod(contractAbi abi.ABI, name string) (abi.Method, error) {
+	name = strings.ReplaceAll(name, " ", "")
+	var found []abi.Method
+	for _, method := range contractAbi.Methods {
+		if method.Sig == name {
+			return method, nil
+		}
+		if method.RawName == name {
+			found = append(found, method)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return abi.Method{}, fmt.Errorf("method %s not found in abi", name)
+	case 1:
+		return found[0], nil
+	default:
+		sigs := make([]string, 0, len(found))
+		for _, method := range found {
+			sigs = append(sigs, method.Sig)
+		}
+		return abi.Method{}, fmt.Errorf("method %s is overloaded, use one of: %s", name, strings.Join(sigs, ", "))
+	}
+}
+
+// SplitArgs splits comma separated method arguments. Commas inside of
+// arrays [..], tuples (..) and quoted strings are not split on.
+func SplitArgs(args string) ([]string, error) {
+	return splitTopLevel(args)
+}
+
+// EncodeCall packs the given string args according to method inputs and
+// prefixes them with the method selector. Returns the call data and the
+// parsed args.
+func EncodeCall(method abi.Method, args []string) ([]byte, []interface{}, error) {
+	if len(args) != len(method.Inputs) {
+		return nil, nil, fmt.Errorf("method %s expects %d args, got %d", method.Sig, len(method.Inputs), len(args))
+	}
+	values := make([]interface{}, 0, len(args))
+	for i, input := range method.Inputs {
+		v, err := parseArgValue(input.Type, args[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid arg %d (%s): %w", i, input.Type, err)
+		}
+		values = append(values, v.Interface())
+	}
+	packed, err := method.Inputs.Pack(values...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(common.CopyBytes(method.ID), packed...), values, nil
+}
+
+// parseArgMarshaling parses a single param type (with optional name) of
+// method signature
+func parseArgMarshaling(param string, defaultName string) (abi.ArgumentMarshaling, error) {
+	param = strings.TrimSpace(param)
+	if param == "" {
+		return abi.ArgumentMarshaling{}, errors.New("empty argument type")
+	}
+	if !strings.HasPrefix(param, "(") {
+		fields := strings.Fields(param)
+		name := defaultName
+		if len(fields) > 1 {
+			name = fields[len(fields)-1]
+		}
+		return abi.ArgumentMarshaling{Name: name, Type: fields[0]}, nil
+	}
+	end, err := matchingParen(param)
+	if err != nil {
+		return abi.ArgumentMarshaling{}, err
+	}
+	params, err := splitTopLevel(param[1:end])
+	if err != nil {
+		return abi.ArgumentMarshaling{}, err
+	}
+	components := make([]abi.ArgumentMarshaling, 0, len(params))
+	for i, p := range params {
+		c, err := parseArgMarshaling(p, fmt.Sprintf("field%d", i))
+		if err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+		components = append(components, c)
+	}
+	// array suffix and name follow the closing paren: "(..)[] name"
+	var suffix string
+	name := defaultName
+	rest := strings.Fields(param[end+1:])
+	if len(rest) > 0 && strings.HasPrefix(rest[0], "[") {
+		suffix = rest[0]
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		name = rest[len(rest)-1]
+	}
+	return abi.ArgumentMarshaling{Name: name, Type: "tuple" + suffix, Components: components}, nil
+}
+
+// parseArgValue converts a string arg into a value packable as abi type t
+func parseArgValue(t abi.Type, s string) (reflect.Value, error) {
+	s = strings.TrimSpace(s)
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, ok := math.ParseBig256(s)
+		if !ok || s == "" {
+			return reflect.Value{}, fmt.Errorf("invalid integer: %s", s)
+		}
+		if t.T == abi.UintTy && (n.Sign() < 0 || n.BitLen() > t.Size) {
+			return reflect.Value{}, fmt.Errorf("%s does not fit into uint%d", s, t.Size)
+		}
+		if t.T == abi.IntTy {
+			bits := n.BitLen()
+			if n.Sign() < 0 {
+				bits = new(big.Int).Add(n, big.NewInt(1)).BitLen()
+			}
+			if bits >= t.Size {
+				return reflect.Value{}, fmt.Errorf("%s does not fit into int%d", s, t.Size)
+			}
+		}
+		typ := t.GetType()
+		if typ == bigIntType {
+			return reflect.ValueOf(n), nil
+		}
+		if t.T == abi.UintTy {
+			return reflect.ValueOf(n.Uint64()).Convert(typ), nil
+		}
+		return reflect.ValueOf(n.Int64()).Convert(typ), nil
+	case abi.BoolTy:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bool: %s", s)
+		}
+		return reflect.ValueOf(b), nil
+	case abi.StringTy:
+		if len(s) >= 2 && strings.HasPrefix(s, "\"") && strings.HasSuffix(s, "\"") {
+			unquoted, err := strconv.Unquote(s)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid string: %s", s)
+			}
+			s = unquoted
+		}
+		return reflect.ValueOf(s), nil
+	case abi.AddressTy:
+		if !common.IsHexAddress(s) {
+			return reflect.Value{}, fmt.Errorf("invalid address: %s", s)
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+	case abi.BytesTy:
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bytes %s: %w", s, err)
+		}
+		return reflect.ValueOf(b), nil
+	case abi.FixedBytesTy:
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bytes%d %s: %w", t.Size, s, err)
+		}
+		if len(b) != t.Size {
+			return reflect.Value{}, fmt.Errorf("bytes%d expects %d bytes, got %d", t.Size, t.Size, len(b))
+		}
+		v := reflect.New(t.GetType()).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v, nil
+	case abi.SliceTy, abi.ArrayTy:
+		if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+			return reflect.Value{}, fmt.Errorf("array must be in brackets: %s", s)
+		}
+		elems, err := splitTopLevel(s[1 : len(s)-1])
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		var v reflect.Value
+		if t.T == abi.SliceTy {
+			v = reflect.MakeSlice(t.GetType(), len(elems), len(elems))
+		} else {
+			if len(elems) != t.Size {
+				return reflect.Value{}, fmt.Errorf("array expects %d elements, got %d", t.Size, len(elems))
+			}
+			v = reflect.New(t.GetType()).Elem()
+		}
+		for i, elem := range elems {
+			ev, err := parseArgValue(*t.Elem, elem)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(i).Set(ev)
+		}
+		return v, nil
+	case abi.TupleTy:
+		if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+			return reflect.Value{}, fmt.Errorf("tuple must be in parentheses: %s", s)
+		}
+		elems, err := splitTopLevel(s[1 : len(s)-1])
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if len(elems) != len(t.TupleElems) {
+			return reflect.Value{}, fmt.Errorf("tuple expects %d elements, got %d", len(t.TupleElems), len(elems))
+		}
+		v := reflect.New(t.TupleType).Elem()
+		for i, elem := range elems {
+			ev, err := parseArgValue(*t.TupleElems[i], elem)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Field(i).Set(ev)
+		}
+		return v, nil
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported arg type: %s", t)
+	}
+}
+
+// splitTopLevel splits s by commas that are not nested in brackets,
+// parentheses or quotes
+func splitTopLevel(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var (
+		parts  []string
+		depth  int
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced brackets: %s", s)
+			}
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if depth != 0 || quoted {
+		return nil, fmt.Errorf("unbalanced brackets or quotes: %s", s)
+	}
+	return append(parts, strings.TrimSpace(s[start:])), nil
+}
+
+// matchingParen returns the index of the paren closing the one at s[0]
+func matchingParen(s string) (int, error) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced parentheses: %s", s)
+}
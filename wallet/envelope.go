@@ -0,0 +1,225 @@
+package wallet
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+)
+
+// An envelope is a file encrypted locally with a random data key, where the
+// data key is wrapped by a hw wallet. The layout is:
+//
+//   Description                          | Length
+//   -------------------------------------+---------------------
+//   Magic "JWENC"                        | 5 bytes
+//   Header length (big endian)           | 4 bytes
+//   Header json                          | header length
+//   Chunks of AES-GCM sealed body        | chunk size + 16 bytes
+//   ...                                  |
+//   Final chunk                          | up to chunk size + 16 bytes
+//
+// Each chunk is sealed with the header as additional data and a nonce of the
+// header nonce prefix, the chunk counter and a final chunk flag, so chunks can
+// not be reordered, dropped or truncated.
+
+// EnvelopeVersion is the version of the envelope written
+const EnvelopeVersion = 1
+
+const (
+	envelopeMagic     = "JWENC"
+	envelopeChunkSize = 64 * 1024
+	envelopeMaxHeader = 64 * 1024
+	dataKeyLen        = 32
+	noncePrefixLen    = 7
+)
+
+var (
+	ErrNotEnvelope        = errors.New("not an encrypted envelope")
+	ErrEnvelopeVersion    = errors.New("unsupported envelope version")
+	ErrEnvelopeCorrupted  = errors.New("envelope is corrupted or the data key is wrong")
+	ErrEnvelopeHeaderSize = errors.New("envelope header too large")
+)
+
+// EnvelopeHeader describes how the data key of an envelope was wrapped, so
+// the file can be decrypted later without extra metadata
+type EnvelopeHeader struct {
+	Version      int           `json:"version"`
+	Address      string        `json:"address,omitempty"`
+	Path         string        `json:"path"`
+	KeyName      string        `json:"key"`
+	AskOnEncrypt bool          `json:"askOnEncrypt"`
+	AskOnDecrypt bool          `json:"askOnDecrypt"`
+	WrappedKey   hexutil.Bytes `json:"wrappedKey"`
+	Nonce        hexutil.Bytes `json:"nonce"`
+
+	raw []byte // the header as written, authenticated with every chunk
+}
+
+// NewDataKey returns a random key to encrypt an envelope body
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, dataKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// WriteEnvelope writes the header and the body read from r encrypted with
+// dataKey to w. The version and nonce of the header are set.
+func WriteEnvelope(w io.Writer, header *EnvelopeHeader, dataKey []byte, r io.Reader) error {
+	header.Version = EnvelopeVersion
+	header.Nonce = make([]byte, noncePrefixLen)
+	if _, err := rand.Read(header.Nonce); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	header.raw = raw
+	aead, err := newEnvelopeAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	prefix := make([]byte, len(envelopeMagic)+4)
+	copy(prefix, envelopeMagic)
+	binary.BigEndian.PutUint32(prefix[len(envelopeMagic):], uint32(len(raw)))
+	if _, err := w.Write(prefix); err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReaderSize(r, envelopeChunkSize)
+	chunk := make([]byte, envelopeChunkSize)
+	sealed := make([]byte, 0, envelopeChunkSize+aead.Overhead())
+	for counter := uint32(0); ; counter++ {
+		n, final, err := readChunk(reader, chunk)
+		if err != nil {
+			return err
+		}
+		sealed = aead.Seal(sealed[:0], chunkNonce(header.Nonce, counter, final), chunk[:n], raw)
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+		if counter == ^uint32(0) {
+			return errors.New("envelope body too large")
+		}
+	}
+}
+
+// ReadEnvelopeHeader reads the header of an envelope. The reader is left at
+// the start of the body.
+func ReadEnvelopeHeader(r io.Reader) (*EnvelopeHeader, error) {
+	prefix := make([]byte, len(envelopeMagic)+4)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNotEnvelope
+		}
+		return nil, err
+	}
+	if string(prefix[:len(envelopeMagic)]) != envelopeMagic {
+		return nil, ErrNotEnvelope
+	}
+	size := binary.BigEndian.Uint32(prefix[len(envelopeMagic):])
+	if size > envelopeMaxHeader {
+		return nil, ErrEnvelopeHeaderSize
+	}
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, ErrEnvelopeCorrupted
+	}
+	header := &EnvelopeHeader{}
+	if err := json.Unmarshal(raw, header); err != nil {
+		return nil, fmt.Errorf("invalid envelope header: %w", err)
+	}
+	if header.Version != EnvelopeVersion {
+		return nil, fmt.Errorf("%w: %d", ErrEnvelopeVersion, header.Version)
+	}
+	if len(header.Nonce) != noncePrefixLen {
+		return nil, ErrEnvelopeCorrupted
+	}
+	header.raw = raw
+	return header, nil
+}
+
+// ReadEnvelopeBody decrypts the body following the header with dataKey to w.
+// Fails if any chunk does not authenticate, in which case w may have received
+// a part of the body already.
+func ReadEnvelopeBody(w io.Writer, r io.Reader, header *EnvelopeHeader, dataKey []byte) error {
+	aead, err := newEnvelopeAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReaderSize(r, envelopeChunkSize+aead.Overhead())
+	chunk := make([]byte, envelopeChunkSize+aead.Overhead())
+	opened := make([]byte, 0, envelopeChunkSize)
+	for counter := uint32(0); ; counter++ {
+		n, final, err := readChunk(reader, chunk)
+		if err != nil {
+			return err
+		}
+		opened, err = aead.Open(opened[:0], chunkNonce(header.Nonce, counter, final), chunk[:n], header.raw)
+		if err != nil {
+			return ErrEnvelopeCorrupted
+		}
+		if _, err := w.Write(opened); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+func newEnvelopeAEAD(dataKey []byte) (cipher.AEAD, error) {
+	if len(dataKey) != dataKeyLen {
+		return nil, fmt.Errorf("invalid data key length: %d", len(dataKey))
+	}
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readChunk fills buf and reports whether it is the last chunk of r
+func readChunk(r *bufio.Reader, buf []byte) (int, bool, error) {
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return n, true, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if _, err := r.Peek(1); err == io.EOF {
+		return n, true, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	return n, false, nil
+}
+
+// chunkNonce returns the 12 bytes GCM nonce of a chunk
+func chunkNonce(prefix []byte, counter uint32, final bool) []byte {
+	nonce := make([]byte, 0, 12)
+	nonce = append(nonce, prefix...)
+	nonce = append(nonce, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(nonce[noncePrefixLen:], counter)
+	if final {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
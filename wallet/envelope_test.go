@@ -0,0 +1,102 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// sealEnvelope writes body to a new envelope and returns it with its data key
+func sealEnvelope(t *testing.T, body []byte) ([]byte, []byte) {
+	t.Helper()
+	dataKey, err := NewDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &EnvelopeHeader{Path: "m/44'/60'/0'/0/0", KeyName: "test", WrappedKey: []byte{1, 2, 3}}
+	var out bytes.Buffer
+	if err := WriteEnvelope(&out, header, dataKey, bytes.NewReader(body)); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes(), dataKey
+}
+
+// openEnvelope reads the header and decrypts the body of an envelope
+func openEnvelope(envelope, dataKey []byte) ([]byte, error) {
+	r := bytes.NewReader(envelope)
+	header, err := ReadEnvelopeHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := ReadEnvelopeBody(&out, r, header, dataKey); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, envelopeChunkSize - 1, envelopeChunkSize, envelopeChunkSize + 1, 3 * envelopeChunkSize} {
+		body := make([]byte, size)
+		if _, err := rand.Read(body); err != nil {
+			t.Fatal(err)
+		}
+		envelope, dataKey := sealEnvelope(t, body)
+		got, err := openEnvelope(envelope, dataKey)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(got, body) {
+			t.Fatalf("size %d: body mismatch", size)
+		}
+	}
+}
+
+func TestEnvelopeTruncatedAtChunk(t *testing.T) {
+	envelope, dataKey := sealEnvelope(t, make([]byte, 2*envelopeChunkSize))
+	// drop the final chunk, leaving a complete first chunk
+	truncated := envelope[:len(envelope)-envelopeChunkSize-16]
+	if _, err := openEnvelope(truncated, dataKey); !errors.Is(err, ErrEnvelopeCorrupted) {
+		t.Fatalf("expected ErrEnvelopeCorrupted, got %v", err)
+	}
+}
+
+func TestEnvelopeHeaderTampered(t *testing.T) {
+	envelope, dataKey := sealEnvelope(t, []byte("secret"))
+	tampered := bytes.Replace(envelope, []byte(`"key":"test"`), []byte(`"key":"TEST"`), 1)
+	if bytes.Equal(tampered, envelope) {
+		t.Fatal("header not tampered")
+	}
+	if _, err := openEnvelope(tampered, dataKey); !errors.Is(err, ErrEnvelopeCorrupted) {
+		t.Fatalf("expected ErrEnvelopeCorrupted, got %v", err)
+	}
+}
+
+func TestEnvelopeWrongDataKey(t *testing.T) {
+	envelope, _ := sealEnvelope(t, []byte("secret"))
+	otherKey, err := NewDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openEnvelope(envelope, otherKey); !errors.Is(err, ErrEnvelopeCorrupted) {
+		t.Fatalf("expected ErrEnvelopeCorrupted, got %v", err)
+	}
+}
+
+func TestEnvelopeWrongVersion(t *testing.T) {
+	envelope, dataKey := sealEnvelope(t, []byte("secret"))
+	tampered := bytes.Replace(envelope, []byte(`"version":1`), []byte(`"version":2`), 1)
+	if bytes.Equal(tampered, envelope) {
+		t.Fatal("version not tampered")
+	}
+	if _, err := openEnvelope(tampered, dataKey); !errors.Is(err, ErrEnvelopeVersion) {
+		t.Fatalf("expected ErrEnvelopeVersion, got %v", err)
+	}
+}
+
+func TestNotEnvelope(t *testing.T) {
+	if _, err := ReadEnvelopeHeader(bytes.NewReader([]byte("plain text file"))); !errors.Is(err, ErrNotEnvelope) {
+		t.Fatalf("expected ErrNotEnvelope, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package wallet
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/accounts/abi"
+)
+
+//go:embed signatures.txt
+var builtinSignatures []byte
+
+// SignatureRegistry maps 4-byte method selectors to known methods and is
+// used to decode tx input offline. Methods from contract ABI files take
+// precedence over plain signatures as they carry argument names.
+type SignatureRegistry struct {
+	methods map[[4]byte][]abi.Method
+}
+
+// DecodedCall is a tx input decoded with a method from the registry.
+type DecodedCall struct {
+	Method abi.Method
+	Args   []interface{}
+	Input  []byte
+}
+
+// NewSignatureRegistry creates a registry preloaded with the builtin
+// signature table
+func NewSignatureRegistry() (*SignatureRegistry, error) {
+	r := &SignatureRegistry{methods: make(map[[4]byte][]abi.Method)}
+	if err := r.loadSignatures(bytes.NewReader(builtinSignatures), false); err != nil {
+		return nil, fmt.Errorf("builtin signatures: %w", err)
+	}
+	return r, nil
+}
+
+// LoadSignatureFile adds signatures from an offline 4byte table. Each line
+// contains a signature optionally prefixed with its hex selector, like
+// "0xa9059cbb transfer(address,uint256)". Lines starting with # are skipped.
+func (r *SignatureRegistry) LoadSignatureFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := r.loadSignatures(f, false); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadABIDir adds methods of all contract ABI json files (*.json) in dir
+func (r *SignatureRegistry) LoadABIDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		contractAbi, err := LoadABI(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return err
+		}
+		for _, method := range contractAbi.Methods {
+			r.add(method, true)
+		}
+	}
+	return nil
+}
+
+// AddMethod adds a method to the registry with precedence over the already
+// known methods with the same selector
+func (r *SignatureRegistry) AddMethod(method abi.Method) {
+	r.add(method, true)
+}
+
+// Decode finds a method matching the input selector and unpacks its args.
+// When several methods share the selector the first one that unpacks the
+// input is returned.
+func (r *SignatureRegistry) Decode(input []byte) (*DecodedCall, error) {
+	if len(input) < 4 {
+		return nil, errors.New("input is shorter than a method selector")
+	}
+	var selector [4]byte
+	copy(selector[:], input[:4])
+	methods := r.methods[selector]
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("unknown method selector: %x", selector)
+	}
+	var lastErr error
+	for _, method := range methods {
+		args, err := method.Inputs.Unpack(input[4:])
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return &DecodedCall{Method: method, Args: args, Input: input}, nil
+	}
+	return nil, fmt.Errorf("input does not match method %s: %w", methods[0].Sig, lastErr)
+}
+
+func (r *SignatureRegistry) loadSignatures(reader io.Reader, first bool) error {
+	scanner := bufio.NewScanner(reader)
+	lineNr := 0
+	for scanner.Scan() {
+		lineNr++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// the selector column is optional, it is always computed from signature
+		if fields := strings.Fields(line); len(fields) > 1 && strings.HasPrefix(fields[0], "0x") && !strings.Contains(fields[0], "(") {
+			line = strings.TrimSpace(line[len(fields[0]):])
+		}
+		method, err := ParseMethodSig(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNr, err)
+		}
+		r.add(method, first)
+	}
+	return scanner.Err()
+}
+
+func (r *SignatureRegistry) add(method abi.Method, first bool) {
+	if len(method.ID) != 4 {
+		return
+	}
+	var selector [4]byte
+	copy(selector[:], method.ID)
+	methods := r.methods[selector]
+	for i, m := range methods {
+		if m.Sig == method.Sig {
+			// replace to get better argument names
+			if first {
+				methods = append(methods[:i], methods[i+1:]...)
+				break
+			}
+			return
+		}
+	}
+	if first {
+		r.methods[selector] = append([]abi.Method{method}, methods...)
+	} else {
+		r.methods[selector] = append(methods, method)
+	}
+}
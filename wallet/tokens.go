@@ -0,0 +1,71 @@
+package wallet
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/common"
+)
+
+// Token describes an ERC-20 token for amount rendering
+type Token struct {
+	Symbol   string
+	Decimals uint8
+}
+
+// well-known tokens by chain id, used when no node is available to ask
+var knownTokens = map[uint64]map[common.Address]Token{
+	1: {
+		common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"): {Symbol: "USDC", Decimals: 6},
+		common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"): {Symbol: "USDT", Decimals: 6},
+		common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"): {Symbol: "DAI", Decimals: 18},
+		common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"): {Symbol: "WETH", Decimals: 18},
+		common.HexToAddress("0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599"): {Symbol: "WBTC", Decimals: 8},
+	},
+}
+
+// ERC-20 methods which last argument is a token amount
+var erc20AmountMethods = map[string]bool{
+	"transfer(address,uint256)":             true,
+	"transferFrom(address,address,uint256)": true,
+	"approve(address,uint256)":              true,
+	"increaseAllowance(address,uint256)":    true,
+	"decreaseAllowance(address,uint256)":    true,
+}
+
+// LookupToken returns a well-known token on the given chain
+func LookupToken(chainID uint64, addr common.Address) (Token, bool) {
+	token, ok := knownTokens[chainID][addr]
+	return token, ok
+}
+
+// IsERC20AmountCall reports whether the call is a known ERC-20 call with a
+// token amount as its last argument
+func IsERC20AmountCall(call *DecodedCall) bool {
+	if call == nil || !erc20AmountMethods[call.Method.Sig] || len(call.Args) == 0 {
+		return false
+	}
+	_, ok := call.Args[len(call.Args)-1].(*big.Int)
+	return ok
+}
+
+// FormatUnits renders an integer amount in token units, like 1500000 with 6
+// decimals as "1.5"
+func FormatUnits(amount *big.Int, decimals uint8) string {
+	if decimals == 0 {
+		return amount.String()
+	}
+	abs := new(big.Int).Abs(amount)
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, frac := new(big.Int).QuoRem(abs, unit, new(big.Int))
+	s := whole.String()
+	if frac.Sign() != 0 {
+		fracStr := frac.String()
+		fracStr = strings.Repeat("0", int(decimals)-len(fracStr)) + fracStr
+		s += "." + strings.TrimRight(fracStr, "0")
+	}
+	if amount.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
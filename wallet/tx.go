@@ -2,7 +2,9 @@ package wallet
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon/common"
@@ -18,48 +20,120 @@ func (tx WrappedLegacyTx) GetChainID() *uint256.Int {
 	return tx.ChainID
 }
 
+// AutoTxType selects the tx type from the fee params: a dynamic fee tx with a
+// gas tip, an access list tx with a gas price and an access list, otherwise a
+// legacy tx
+const AutoTxType = -1
+
 func NewTx(chainID uint256.Int, nonce uint64, to *common.Address, value *uint256.Int, input []byte, gasLimit uint64, gasPrice, gasTip, gasFeeCap *uint256.Int) (types.Transaction, error) {
-	var rawTx types.Transaction
-	if gasTip != nil {
-		if gasFeeCap == nil {
-			gasFeeCap = gasPrice
+	return NewTypedTx(AutoTxType, chainID, nonce, to, value, input, gasLimit, gasPrice, gasTip, gasFeeCap, nil)
+}
+
+// NewTypedTx creates a legacy (0), access list (1) or dynamic fee (2) tx. A
+// dynamic fee tx with only a gas price uses it as both the tip and fee cap.
+func NewTypedTx(txType int, chainID uint256.Int, nonce uint64, to *common.Address, value *uint256.Int, input []byte, gasLimit uint64, gasPrice, gasTip, gasFeeCap *uint256.Int, accessList types.AccessList) (types.Transaction, error) {
+	if txType == AutoTxType {
+		switch {
+		case gasTip != nil:
+			txType = types.DynamicFeeTxType
+		case gasPrice != nil && len(accessList) > 0:
+			txType = types.AccessListTxType
+		case gasPrice != nil:
+			txType = types.LegacyTxType
+		default:
+			return nil, errors.New("Either --gas-price or (--gas-tip and --gas-price) must be specified")
 		}
-		tx := &types.DynamicFeeTransaction{
-			CommonTx: types.CommonTx{
-				Nonce: nonce,
-				Data:  input,
-				Gas:   gasLimit,
-				Value: value,
-			},
-			ChainID: &chainID,
-			Tip:     gasTip,
-			FeeCap:  gasFeeCap,
+	}
+	commonTx := types.CommonTx{
+		Nonce: nonce,
+		To:    to,
+		Data:  input,
+		Gas:   gasLimit,
+		Value: value,
+	}
+	var tx types.Transaction
+	switch txType {
+	case types.LegacyTxType, types.AccessListTxType:
+		if txType == types.LegacyTxType && len(accessList) > 0 {
+			return nil, errors.New("a legacy (type 0) tx can not have an access list")
 		}
-		if to != nil {
-			tx.To = to
+		if gasPrice == nil {
+			return nil, fmt.Errorf("a type %d tx needs --gasprice", txType)
 		}
-		rawTx = tx
-	} else if gasPrice != nil {
-		tx := &WrappedLegacyTx{
+		tx = &WrappedLegacyTx{
 			LegacyTx: types.LegacyTx{
-				CommonTx: types.CommonTx{
-					Nonce: nonce,
-					Data:  input,
-					Gas:   gasLimit,
-					Value: value,
-				},
+				CommonTx: commonTx,
 				GasPrice: gasPrice,
 			},
 			ChainID: &chainID,
 		}
-		if to != nil {
-			tx.To = to
+	case types.DynamicFeeTxType:
+		if gasTip == nil {
+			gasTip = gasPrice
+		}
+		if gasFeeCap == nil {
+			gasFeeCap = gasPrice
+		}
+		if gasTip == nil || gasFeeCap == nil {
+			return nil, errors.New("a dynamic fee (type 2) tx needs --gastip and --gasfeecap")
+		}
+		tx = &types.DynamicFeeTransaction{
+			CommonTx: commonTx,
+			ChainID:  &chainID,
+			Tip:      gasTip,
+			FeeCap:   gasFeeCap,
 		}
-		rawTx = tx
-	} else {
-		return nil, errors.New("Either --gas-price or (--gas-tip and --gas-price) must be specified")
+	default:
+		return nil, fmt.Errorf("unsupported tx type: %d", txType)
+	}
+	if txType == types.LegacyTxType {
+		return tx, nil
+	}
+	return WithAccessList(tx, accessList)
+}
+
+// ParseTxType parses a tx type of 0, 1 or 2. Empty selects AutoTxType.
+func ParseTxType(s string) (int, error) {
+	switch s {
+	case "":
+		return AutoTxType, nil
+	case "0", "legacy":
+		return types.LegacyTxType, nil
+	case "1", "access-list":
+		return types.AccessListTxType, nil
+	case "2", "dynamic-fee":
+		return types.DynamicFeeTxType, nil
+	default:
+		return 0, fmt.Errorf("invalid tx type %q, expected 0, 1 or 2", s)
+	}
+}
+
+// WithAccessList adds an EIP-2930 access list to a tx. A legacy tx becomes an
+// access list (type 1) tx, also with an empty access list.
+func WithAccessList(tx types.Transaction, accessList types.AccessList) (types.Transaction, error) {
+	switch t := tx.(type) {
+	case *types.DynamicFeeTransaction:
+		t.AccessList = accessList
+		return t, nil
+	case *WrappedLegacyTx:
+		return &types.AccessListTx{
+			LegacyTx:   t.LegacyTx,
+			ChainID:    t.ChainID,
+			AccessList: accessList,
+		}, nil
+	default:
+		return nil, fmt.Errorf("can not add access list to tx type %d", tx.Type())
+	}
+}
+
+// ParseAccessList parses a JSON access list like
+// [{"address":"0x..","storageKeys":["0x.."]}]
+func ParseAccessList(data []byte) (types.AccessList, error) {
+	var accessList types.AccessList
+	if err := json.Unmarshal(data, &accessList); err != nil {
+		return nil, fmt.Errorf("invalid access list: %w", err)
 	}
-	return rawTx, nil
+	return accessList, nil
 }
 
 func EncodeTx(signed types.Transaction) ([]byte, error) {
@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+var (
+	testTo         = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	testAccessList = types.AccessList{{Address: testTo, StorageKeys: []common.Hash{{0x01}}}}
+)
+
+func newTestTx(txType int, gasPrice, gasTip, gasFeeCap *uint256.Int, accessList types.AccessList) (types.Transaction, error) {
+	return NewTypedTx(txType, *uint256.NewInt(1), 0, &testTo, uint256.NewInt(0), nil, 21000, gasPrice, gasTip, gasFeeCap, accessList)
+}
+
+func TestNewTypedTxAuto(t *testing.T) {
+	tests := []struct {
+		name       string
+		gasPrice   *uint256.Int
+		gasTip     *uint256.Int
+		gasFeeCap  *uint256.Int
+		accessList types.AccessList
+		txType     byte
+	}{
+		{name: "tip", gasTip: uint256.NewInt(2), gasFeeCap: uint256.NewInt(100), txType: types.DynamicFeeTxType},
+		{name: "tip and access list", gasTip: uint256.NewInt(2), gasFeeCap: uint256.NewInt(100), accessList: testAccessList, txType: types.DynamicFeeTxType},
+		{name: "price and access list", gasPrice: uint256.NewInt(100), accessList: testAccessList, txType: types.AccessListTxType},
+		{name: "price", gasPrice: uint256.NewInt(100), txType: types.LegacyTxType},
+	}
+	for _, test := range tests {
+		tx, err := newTestTx(AutoTxType, test.gasPrice, test.gasTip, test.gasFeeCap, test.accessList)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if tx.Type() != test.txType {
+			t.Errorf("%s: type %d, want %d", test.name, tx.Type(), test.txType)
+		}
+		if len(tx.GetAccessList()) != len(test.accessList) {
+			t.Errorf("%s: access list %v, want %v", test.name, tx.GetAccessList(), test.accessList)
+		}
+	}
+	if _, err := newTestTx(AutoTxType, nil, nil, nil, nil); err == nil {
+		t.Error("expected an error without fees")
+	}
+}
+
+func TestNewTypedTxLegacyAccessList(t *testing.T) {
+	if _, err := newTestTx(types.LegacyTxType, uint256.NewInt(100), nil, nil, testAccessList); err == nil {
+		t.Fatal("expected an error for a type 0 tx with an access list")
+	}
+}
+
+func TestNewTypedTxDynamicFeeFromGasPrice(t *testing.T) {
+	tx, err := newTestTx(types.DynamicFeeTxType, uint256.NewInt(100), nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Type() != types.DynamicFeeTxType || tx.GetTip().Uint64() != 100 || tx.GetFeeCap().Uint64() != 100 {
+		t.Fatalf("unexpected tx: type %d, tip %v, fee cap %v", tx.Type(), tx.GetTip(), tx.GetFeeCap())
+	}
+}
+
+func TestNewTypedTxAccessListEmpty(t *testing.T) {
+	tx, err := newTestTx(types.AccessListTxType, uint256.NewInt(100), nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tx.(*types.AccessListTx); !ok {
+		t.Fatalf("got %T, want *types.AccessListTx", tx)
+	}
+	if tx.GetChainID().Uint64() != 1 || tx.GetPrice().Uint64() != 100 {
+		t.Fatalf("unexpected tx: chain id %v, gas price %v", tx.GetChainID(), tx.GetPrice())
+	}
+}
+
+func TestWithAccessListUnsupported(t *testing.T) {
+	tx, err := newTestTx(types.AccessListTxType, uint256.NewInt(100), nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WithAccessList(tx, testAccessList); err == nil {
+		t.Fatal("expected an error adding an access list to an access list tx")
+	}
+}
+
+func TestParseTxType(t *testing.T) {
+	tests := map[string]int{
+		"":            AutoTxType,
+		"0":           types.LegacyTxType,
+		"legacy":      types.LegacyTxType,
+		"1":           types.AccessListTxType,
+		"access-list": types.AccessListTxType,
+		"2":           types.DynamicFeeTxType,
+		"dynamic-fee": types.DynamicFeeTxType,
+	}
+	for s, want := range tests {
+		got, err := ParseTxType(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got %d, %v, want %d", s, got, err, want)
+		}
+	}
+	if _, err := ParseTxType("3"); err == nil {
+		t.Error("expected an error for tx type 3")
+	}
+}
@@ -0,0 +1,112 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// mailTypedData is the "Mail" example of EIP-712
+const mailTypedData = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": 1,
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+// amountTypedData returns a document with a single uint256 message value
+func amountTypedData(amount string) []byte {
+	return []byte(`{
+  "types": {
+    "EIP712Domain": [{"name": "name", "type": "string"}],
+    "Transfer": [{"name": "amount", "type": "uint256"}]
+  },
+  "primaryType": "Transfer",
+  "domain": {"name": "Test"},
+  "message": {"amount": ` + amount + `}
+}`)
+}
+
+func typedDataHash(t *testing.T, data []byte) []byte {
+	t.Helper()
+	typedData, err := ParseTypedData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, hash, err := TypedDataAndHash(typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestTypedDataMailHash(t *testing.T) {
+	want := "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"
+	if got := hex.EncodeToString(typedDataHash(t, []byte(mailTypedData))); got != want {
+		t.Fatalf("hash: got %s, want %s", got, want)
+	}
+}
+
+func TestTypedDataLargeInteger(t *testing.T) {
+	// 2^53+1 is not representable as a float64
+	number := typedDataHash(t, amountTypedData("9007199254740993"))
+	str := typedDataHash(t, amountTypedData(`"9007199254740993"`))
+	if !bytes.Equal(number, str) {
+		t.Fatalf("json number and string of the same value hash differently: %x, %x", number, str)
+	}
+	if rounded := typedDataHash(t, amountTypedData("9007199254740992")); bytes.Equal(number, rounded) {
+		t.Fatal("integer above 2^53 was rounded")
+	}
+	max := typedDataHash(t, amountTypedData("115792089237316195423570985008687907853269984665640564039457584007913129639935"))
+	if bytes.Equal(max, number) {
+		t.Fatal("max uint256 hashes like 2^53+1")
+	}
+}
+
+func TestTypedDataInvalidInteger(t *testing.T) {
+	for _, amount := range []string{"1.5", "1e3", "-1", `"0x1g"`} {
+		typedData, err := ParseTypedData(amountTypedData(amount))
+		if err != nil {
+			continue
+		}
+		if _, _, _, err := TypedDataAndHash(typedData); err == nil {
+			t.Errorf("amount %s: expected an error", amount)
+		}
+	}
+}
+
+func TestParseIntegerFloat(t *testing.T) {
+	if b, err := parseInteger("uint256", float64(42)); err != nil || b.Int64() != 42 {
+		t.Fatalf("float 42: got %v, %v", b, err)
+	}
+	for _, v := range []float64{1.5, 1 << 54} {
+		if _, err := parseInteger("uint256", v); err == nil {
+			t.Errorf("float %v: expected an error", v)
+		}
+	}
+}
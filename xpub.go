@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jaanek/jethwallet/flags"
+	"github.com/jaanek/jethwallet/hdkey"
+	"github.com/jaanek/jethwallet/hwwallet"
+	"github.com/jaanek/jethwallet/hwwallet/hwcommon"
+	"github.com/jaanek/jethwallet/ui"
+)
+
+type XPubOutput struct {
+	XPub string `json:"xpub"`
+	Path string `json:"path"`
+}
+
+func ExportXPub(term ui.Screen, flag *flags.Flags) error {
+	walletType := hwcommon.GetWalletTypeFromFlags(flag)
+	key, err := hwwallet.XPub(term, walletType, flag.FlagXPubPath)
+	if err != nil {
+		return err
+	}
+	xpub := key.String()
+	term.Result(fmt.Sprintf("%s\n", xpub), &XPubOutput{XPub: xpub, Path: flag.FlagXPubPath})
+	return nil
+}
+
+// ListWatchAccounts lists accounts derived offline from --xpub or from the
+// xpub fetched once from the device. The child paths of --xpub are reported
+// only with a given --xpub-path matching the key.
+func ListWatchAccounts(term ui.Screen, flag *flags.Flags) error {
+	var key *hdkey.ExtendedKey
+	var err error
+	if flag.FlagXPub != "" {
+		key, err = hdkey.ParseXPub(flag.FlagXPub)
+		if err == nil && flag.FlagXPubPath != "" {
+			err = hwwallet.CheckXPubPath(key, flag.FlagXPubPath)
+		}
+	} else {
+		walletType := hwcommon.GetWalletTypeFromFlags(flag)
+		key, err = hwwallet.XPub(term, walletType, flag.FlagXPubPath)
+	}
+	if err != nil {
+		return err
+	}
+	return hwwallet.ListWatchAccounts(term, key, flag.FlagXPubPath, flag.Max, flag.FlagVerbose)
+}